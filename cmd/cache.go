@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/dantezy/cold-send0r-bot/internal/cache"
+)
+
+var cachePurgeExpired bool
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Inspect or clear the scrape cache",
+}
+
+var cacheStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show scrape cache size and age",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		stats, err := scrapeCache().Stats()
+		if err != nil {
+			return fmt.Errorf("reading cache: %w", err)
+		}
+		if len(stats) == 0 {
+			fmt.Printf("cache at %s is empty\n", cfg.Cache.Dir)
+			return nil
+		}
+
+		fmt.Printf("cache at %s (ttl %dh)\n\n", cfg.Cache.Dir, cfg.Cache.TTLHours)
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAMESPACE\tENTRIES\tEXPIRED\tSIZE\tOLDEST\tNEWEST")
+		for _, s := range stats {
+			fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\t%s\n", s.Namespace, s.Entries, s.Expired, formatBytes(s.Bytes), formatTime(s.Oldest), formatTime(s.Newest))
+		}
+		return w.Flush()
+	},
+}
+
+var cachePurgeCmd = &cobra.Command{
+	Use:   "purge",
+	Short: "Delete cached scrape results and pages",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		removed, err := scrapeCache().Purge(cachePurgeExpired)
+		if err != nil {
+			return fmt.Errorf("purging cache: %w", err)
+		}
+		log.Info().Str("dir", cfg.Cache.Dir).Int("removed", removed).Bool("expired_only", cachePurgeExpired).Msg("cache purged")
+		return nil
+	},
+}
+
+// scrapeCache opens the cache directory even when caching is disabled, so
+// leftovers can still be inspected and purged.
+func scrapeCache() *cache.Cache {
+	return cache.New(cfg.Cache.Dir, time.Duration(cfg.Cache.TTLHours)*time.Hour)
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+func init() {
+	cachePurgeCmd.Flags().BoolVar(&cachePurgeExpired, "expired", false, "only delete entries older than the TTL")
+	cacheCmd.AddCommand(cacheStatsCmd, cachePurgeCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
@@ -0,0 +1,332 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/dantezy/cold-send0r-bot/internal/contacts"
+	"github.com/dantezy/cold-send0r-bot/internal/ledger"
+	"github.com/dantezy/cold-send0r-bot/internal/models"
+	"github.com/dantezy/cold-send0r-bot/internal/verify"
+)
+
+// validateWorkers is how many addresses are checked at once.
+const validateWorkers = 8
+
+var (
+	importMap    []string
+	importOutput string
+	importForce  bool
+
+	validateOutput string
+
+	mergeOutput    string
+	mergePolicy    string
+	mergeFoldGmail bool
+	mergeForce     bool
+)
+
+var contactsCmd = &cobra.Command{
+	Use:   "contacts",
+	Short: "Import, validate and merge contact lists",
+}
+
+var contactsImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Convert a CSV, TSV, XLSX or JSON Lines export into contacts JSON",
+	Long: `Reads a spreadsheet or CRM export, maps its columns to contact fields and
+writes the valid rows as contacts JSON. Columns such as "Work Email" or
+"Website" are recognized; map others with --map "Header=field", where field
+is email, name, first_name, last_name, company, role or url. Any other field
+name keeps the column as a custom field, and "-" drops it.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mapping := make(map[string]string, len(cfg.Contacts.Mapping)+len(importMap))
+		for k, v := range cfg.Contacts.Mapping {
+			mapping[k] = v
+		}
+		for _, m := range importMap {
+			header, field, ok := strings.Cut(m, "=")
+			if !ok || strings.TrimSpace(header) == "" || strings.TrimSpace(field) == "" {
+				return fmt.Errorf("invalid --map %q, want \"Header=field\"", m)
+			}
+			mapping[header] = field
+		}
+
+		out := importOutput
+		if out == "" {
+			out = cfg.Contacts.Path
+		}
+		if strings.ToLower(filepath.Ext(out)) != ".json" {
+			return fmt.Errorf("output %s must be a .json file", out)
+		}
+		if abs(out) == abs(args[0]) {
+			return fmt.Errorf("output %s is the file being imported", out)
+		}
+		if _, err := os.Stat(out); err == nil && !importForce {
+			return fmt.Errorf("%s already exists, pass --force to overwrite it", out)
+		}
+
+		recs, rejected, err := contacts.Read(args[0], mapping)
+		if err != nil {
+			return err
+		}
+		valid, invalid := contacts.Validate(recs)
+		rejected = append(rejected, invalid...)
+		sort.Slice(rejected, func(i, j int) bool { return rejected[i].Row < rejected[j].Row })
+
+		if len(rejected) > 0 {
+			fmt.Printf("%d rows rejected:\n\n", len(rejected))
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ROW\tEMAIL\tREASON")
+			for _, r := range rejected {
+				fmt.Fprintf(w, "%d\t%s\t%s\n", r.Row, r.Email, r.Reason)
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			fmt.Println()
+		}
+		if len(valid) == 0 {
+			return fmt.Errorf("no valid contacts in %s", args[0])
+		}
+
+		if err := contacts.Write(out, valid); err != nil {
+			return err
+		}
+		log.Info().Str("path", out).Int("imported", len(valid)).Int("rejected", len(rejected)).Msg("contacts imported")
+		return nil
+	},
+}
+
+var contactsValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check contact email addresses for typos, dead domains and role accounts",
+	Long: `Checks every address in the contact list: syntax, common domain typos,
+disposable domains, role and no-reply accounts, and MX/A records unless
+validation.skip_dns is set. Prints the addresses that are not valid, with a
+suggested correction where there is one. With -o the contacts are written
+with their verdicts, which generate and send then reuse.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if validateOutput != "" && strings.ToLower(filepath.Ext(validateOutput)) != ".json" {
+			return fmt.Errorf("output %s must be a .json file", validateOutput)
+		}
+		list, err := loadContacts(nil)
+		if err != nil {
+			return err
+		}
+		for i := range list {
+			list[i].Validation = nil
+		}
+		validateContacts(cmd.Context(), list)
+
+		counts := make(map[string]int)
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		header := false
+		for _, c := range list {
+			v := c.Validation
+			counts[v.Verdict]++
+			if v.Verdict == models.VerdictValid {
+				continue
+			}
+			if !header {
+				fmt.Fprintln(w, "EMAIL\tVERDICT\tREASONS\tSUGGESTION")
+				header = true
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.Email, v.Verdict, strings.Join(v.Reasons, "; "), v.Suggestion)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		if validateOutput != "" {
+			if err := contacts.Write(validateOutput, list); err != nil {
+				return err
+			}
+		}
+		log.Info().
+			Int("valid", counts[models.VerdictValid]).
+			Int("risky", counts[models.VerdictRisky]).
+			Int("invalid", counts[models.VerdictInvalid]).
+			Int("unknown", counts[models.VerdictUnknown]).
+			Str("output", validateOutput).
+			Msg("contacts validated")
+		return nil
+	},
+}
+
+var contactsMergeCmd = &cobra.Command{
+	Use:   "merge <file>...",
+	Short: "Combine contact lists, merging people listed more than once",
+	Long: `Reads each file in order (any format contacts.path accepts), merges
+records with the same email address field by field and writes one contacts
+JSON. Blank fields are filled from the other record; when both are set the
+policy decides, and every conflict is reported. Records are checked after
+merging, so a row missing a name is kept if another file has it. The output
+may be one of the inputs, e.g. to fold a fresh export into contacts.json.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if strings.ToLower(filepath.Ext(mergeOutput)) != ".json" {
+			return fmt.Errorf("output %s must be a .json file", mergeOutput)
+		}
+		opts := mergeOptions()
+		if mergePolicy != "" {
+			opts.Policy = mergePolicy
+		}
+		if opts.Policy != contacts.KeepFirst && opts.Policy != contacts.KeepLast {
+			return fmt.Errorf("--policy must be first or last, not %q", opts.Policy)
+		}
+		if cmd.Flags().Changed("fold-gmail") {
+			opts.FoldGmail = mergeFoldGmail
+		}
+
+		isInput := slices.ContainsFunc(args, func(p string) bool { return abs(p) == abs(mergeOutput) })
+		if _, err := os.Stat(mergeOutput); err == nil && !isInput && !mergeForce {
+			return fmt.Errorf("%s already exists, pass --force to overwrite it", mergeOutput)
+		}
+
+		var all []models.Contact
+		var skipped int
+		for _, path := range args {
+			recs, rejected, err := contacts.Read(path, cfg.Contacts.Mapping)
+			if err != nil {
+				return err
+			}
+			for _, r := range rejected {
+				log.Warn().Str("file", path).Int("row", r.Row).Str("email", r.Email).Str("reason", r.Reason).Msg("skipping unreadable row")
+				skipped++
+			}
+			for _, r := range recs {
+				all = append(all, r.Contact)
+			}
+			log.Info().Str("file", path).Int("contacts", len(recs)).Msg("contacts read")
+		}
+
+		combined, merges := contacts.Dedupe(all, opts)
+		recs := make([]contacts.Record, len(combined))
+		for i, c := range combined {
+			recs[i] = contacts.Record{Row: i + 1, Contact: c}
+		}
+		merged, invalid := contacts.Validate(recs)
+		for _, r := range invalid {
+			log.Warn().Str("email", r.Email).Str("reason", r.Reason).Msg("skipping invalid contact")
+			skipped++
+		}
+		conflicts := 0
+		if len(merges) > 0 {
+			fmt.Printf("%d contacts merged (policy %s):\n\n", len(merges), opts.Policy)
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "EMAIL\tMERGED")
+			for _, m := range merges {
+				conflicts += len(m.Conflicts)
+				fmt.Fprintf(w, "%s\t%s\n", m.Email, m.Summary())
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			fmt.Println()
+		}
+
+		if err := contacts.Write(mergeOutput, merged); err != nil {
+			return err
+		}
+		log.Info().Str("path", mergeOutput).Int("contacts", len(merged)).Int("merged", len(merges)).Int("conflicts", conflicts).Int("skipped", skipped).Msg("contacts written")
+		return nil
+	},
+}
+
+// loadContacts loads the configured contact list.
+func loadContacts(led *ledger.Ledger) ([]models.Contact, error) {
+	return contacts.Load(cfg.Contacts.Path, contacts.Options{Ledger: led, Mapping: cfg.Contacts.Mapping, Dedupe: mergeOptions()})
+}
+
+func mergeOptions() contacts.MergeOptions {
+	return contacts.MergeOptions{Policy: cfg.Contacts.Dedupe.Policy, FoldGmail: cfg.Contacts.Dedupe.FoldGmail}
+}
+
+// validateContacts checks the address of every contact in list that has
+// no verdict yet, in place.
+func validateContacts(ctx context.Context, list []models.Contact) {
+	checker := verify.NewChecker(cfg.Validation, net.DefaultResolver)
+	jobs := make(chan *models.Contact)
+	var wg sync.WaitGroup
+	for range min(validateWorkers, len(list)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range jobs {
+				c.Validation = checker.Check(ctx, c.Email)
+			}
+		}()
+	}
+	for i := range list {
+		if list[i].Validation == nil {
+			jobs <- &list[i]
+		}
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// screenContacts validates list when validation is enabled and drops the
+// contacts whose verdict is blocked, so no email is generated for them.
+func screenContacts(ctx context.Context, list []models.Contact) []models.Contact {
+	if !cfg.Validation.Enabled {
+		return list
+	}
+	validateContacts(ctx, list)
+	var kept []models.Contact
+	for _, c := range list {
+		if blocked(c) {
+			v := c.Validation
+			log.Warn().Str("email", c.Email).Str("verdict", v.Verdict).Strs("reasons", v.Reasons).Str("suggestion", v.Suggestion).Msg("skipping, address failed validation")
+			continue
+		}
+		kept = append(kept, c)
+	}
+	return kept
+}
+
+// blocked reports whether c's validation verdict is one send refuses.
+func blocked(c models.Contact) bool {
+	return c.Validation != nil && slices.Contains(cfg.Validation.Block, c.Validation.Verdict)
+}
+
+func abs(path string) string {
+	if p, err := filepath.Abs(path); err == nil {
+		return p
+	}
+	return path
+}
+
+func init() {
+	contactsImportCmd.Flags().StringSliceVar(&importMap, "map", nil, `column mapping, e.g. "Work Email=email,Website=url" (repeatable)`)
+	contactsImportCmd.Flags().StringVarP(&importOutput, "output", "o", "", "output path (default: contacts.path from the config)")
+	contactsImportCmd.Flags().BoolVar(&importForce, "force", false, "overwrite the output file if it exists")
+	addCampaignFlag(contactsImportCmd)
+	contactsCmd.AddCommand(contactsImportCmd)
+
+	contactsValidateCmd.Flags().StringVarP(&validateOutput, "output", "o", "", "write the contacts with their verdicts to this JSON file")
+	addCampaignFlag(contactsValidateCmd)
+	contactsCmd.AddCommand(contactsValidateCmd)
+
+	contactsMergeCmd.Flags().StringVarP(&mergeOutput, "output", "o", "", "output JSON file (required)")
+	contactsMergeCmd.Flags().StringVar(&mergePolicy, "policy", "", "which value wins a conflict: first or last (default: contacts.dedupe.policy)")
+	contactsMergeCmd.Flags().BoolVar(&mergeFoldGmail, "fold-gmail", false, "treat Gmail addresses differing only in dots or a +tag as one person (default: contacts.dedupe.fold_gmail)")
+	contactsMergeCmd.Flags().BoolVar(&mergeForce, "force", false, "overwrite the output file if it exists")
+	_ = contactsMergeCmd.MarkFlagRequired("output")
+	addCampaignFlag(contactsMergeCmd)
+	contactsCmd.AddCommand(contactsMergeCmd)
+	rootCmd.AddCommand(contactsCmd)
+}
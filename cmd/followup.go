@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/dantezy/cold-send0r-bot/internal/followup"
+	"github.com/dantezy/cold-send0r-bot/internal/generator"
+	"github.com/dantezy/cold-send0r-bot/internal/ledger"
+	"github.com/dantezy/cold-send0r-bot/internal/models"
+	"github.com/dantezy/cold-send0r-bot/internal/output"
+	"github.com/dantezy/cold-send0r-bot/internal/sender"
+)
+
+var (
+	followupInput   string
+	followupConfirm bool
+)
+
+var followupCmd = &cobra.Command{
+	Use:   "followup",
+	Short: "Draft and send follow-ups for sent emails that got no reply",
+	Long: "Finds sent emails with no recorded reply whose next follow-up step is due, drafts the follow-up with the LLM " +
+		"and stores it in the emails file. With --confirm the due follow-ups are sent as replies in the original thread.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		steps := cfg.Followup.Steps
+		if len(steps) == 0 {
+			return fmt.Errorf("no follow-up steps configured (set followups.steps in config)")
+		}
+		if followupInput == "" {
+			followupInput = cfg.Output.Path
+		}
+
+		emails, err := output.ReadEmails(followupInput)
+		if err != nil {
+			return err
+		}
+
+		gen, err := newGenerator()
+		if err != nil {
+			return err
+		}
+		writer, err := generator.NewFollowUpWriter(gen, cfg.Followup)
+		if err != nil {
+			return err
+		}
+
+		var smtpSender *sender.SMTPSender
+		var led *ledger.Ledger
+		if followupConfirm {
+			smtpSender, err = newSMTPSender(nil)
+			if err != nil {
+				return err
+			}
+			led, err = ledger.Open(cfg.Ledger.Path)
+			if err != nil {
+				return err
+			}
+			defer led.Close()
+		}
+
+		now := time.Now()
+		var drafted, sent, failed int
+		for i := range emails {
+			if ctx.Err() != nil {
+				log.Warn().Msg("interrupted, saving progress")
+				break
+			}
+
+			email := &emails[i]
+			step := followup.Next(*email, steps, now)
+			if step == 0 {
+				continue
+			}
+
+			fu := pendingFollowUp(email, step)
+			if fu == nil {
+				days := followup.BusinessDaysBetween(*email.SentAt, now)
+				log.Info().Str("to", email.Contact.Email).Int("step", step).Int("business_days", days).Msg("drafting follow-up")
+				draft, err := writer.Write(ctx, *email, step, days)
+				if err != nil {
+					log.Error().Str("to", email.Contact.Email).Int("step", step).Err(err).Msg("follow-up generation failed")
+					failed++
+					continue
+				}
+				email.FollowUps = append(email.FollowUps, *draft)
+				fu = &email.FollowUps[len(email.FollowUps)-1]
+				drafted++
+			}
+
+			if !followupConfirm {
+				continue
+			}
+
+			if err := smtpSender.SendFollowUp(email, fu); err != nil {
+				failed++
+				continue
+			}
+			sent++
+			if err := led.Record(ledger.Entry{Email: email.Contact.Email, MessageID: fu.MessageID, Subject: fu.Subject, Campaign: cfg.Campaign, SentAt: *fu.SentAt}); err != nil {
+				log.Error().Str("to", email.Contact.Email).Err(err).Msg("failed to record follow-up in ledger")
+			}
+		}
+
+		if err := output.WriteEmails(followupInput, emails); err != nil {
+			return err
+		}
+
+		log.Info().Int("drafted", drafted).Int("sent", sent).Int("failed", failed).Msg("follow-up run complete")
+		if !followupConfirm && drafted > 0 {
+			log.Info().Str("path", followupInput).Msg("review the follow_ups drafts, then run: send0r followup --confirm")
+		}
+		return ctx.Err()
+	},
+}
+
+// pendingFollowUp returns the unsent draft for step, if one was generated
+// by an earlier run.
+func pendingFollowUp(email *models.Email, step int) *models.FollowUp {
+	for i := range email.FollowUps {
+		if email.FollowUps[i].Step == step && email.FollowUps[i].SentAt == nil {
+			return &email.FollowUps[i]
+		}
+	}
+	return nil
+}
+
+func init() {
+	followupCmd.Flags().StringVar(&followupInput, "input", "", "path to emails JSON file (default: from config)")
+	followupCmd.Flags().BoolVar(&followupConfirm, "confirm", false, "send due follow-ups (otherwise only draft them)")
+	addCampaignFlag(followupCmd)
+	rootCmd.AddCommand(followupCmd)
+}
@@ -1,29 +1,44 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 
-	"github.com/dantezy/cold-send0r-bot/internal/contacts"
+	"github.com/dantezy/cold-send0r-bot/internal/enrich"
 	"github.com/dantezy/cold-send0r-bot/internal/generator"
 	"github.com/dantezy/cold-send0r-bot/internal/models"
 	"github.com/dantezy/cold-send0r-bot/internal/output"
 	"github.com/dantezy/cold-send0r-bot/internal/resume"
+	"github.com/dantezy/cold-send0r-bot/internal/scraper"
 )
 
 var (
 	generateScrapeInput string
+	generateRetryFailed bool
 )
 
 var generateCmd = &cobra.Command{
 	Use:   "generate",
 	Short: "Generate personalized emails from scraped data",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		contactList, err := contacts.Load(cfg.Contacts.Path)
+		sel, err := newSelection()
+		if err != nil {
+			return err
+		}
+
+		led := openLedger()
+		if led != nil {
+			defer led.Close()
+		}
+
+		contactList, err := loadContacts(led)
 		if err != nil {
 			return err
 		}
@@ -49,32 +64,139 @@ var generateCmd = &cobra.Command{
 			}
 		}
 
-		gen := generator.NewGenerator(cfg.LLM, cfg.Sender.Name)
-		var emails []models.Email
+		// With --retry-failed only the failed records of the previous run are
+		// regenerated; everything else in the output file is left untouched.
+		var existing []models.Email
+		targets := contactList
+		if generateRetryFailed {
+			existing, err = output.ReadEmails(cfg.Output.Path)
+			if err != nil {
+				return err
+			}
+			targets = nil
+			for _, e := range existing {
+				if e.Status == models.StatusGenerationFailed {
+					targets = append(targets, e.Contact)
+				}
+			}
+		}
+
+		status, err := sel.statuses(cfg.Output.Path, led)
+		if err != nil {
+			return err
+		}
+		targets = sel.contacts(targets, status)
+		switch {
+		case len(targets) > 0:
+		case generateRetryFailed:
+			log.Info().Str("path", cfg.Output.Path).Msg("no failed generations to retry")
+			return nil
+		case sel.active():
+			log.Info().Msg("no contacts match the selection")
+			return nil
+		}
+		targets = screenContacts(cmd.Context(), targets)
+
+		res, err := newResolver()
+		if err != nil {
+			return err
+		}
+		reqs := make([]generator.Request, 0, len(targets))
+		for _, c := range targets {
+			reqs = append(reqs, newRequest(cmd.Context(), res, c, scrapeMap[c.URL], resumeText))
+		}
 
-		for i, c := range contactList {
-			log.Info().Int("index", i+1).Int("total", len(contactList)).Str("contact", c.Name).Str("company", c.Company).Msg("generating email")
+		gen, err := newGenerator()
+		if err != nil {
+			return err
+		}
+		pool := generator.NewPool(gen, cfg.LLM)
+		generated := make(map[string]*models.Email)
+		var failed int
 
-			scrapeResult := scrapeMap[c.URL]
-			email, err := gen.Generate(c, scrapeResult, resumeText, cfg.Sender.Links)
+		log.Info().Int("count", len(reqs)).Int("concurrency", cfg.LLM.Concurrency).Str("model", cfg.LLM.Model).Msg("generating personalized emails")
+		runErr := pool.Run(cmd.Context(), reqs, func(req generator.Request, email *models.Email, err error) {
+			generated[strings.ToLower(req.Contact.Email)] = email
 			if err != nil {
-				log.Error().Str("contact", c.Name).Err(err).Msg("generation failed")
-				continue
+				failed++
+				log.Error().Str("contact", req.Contact.Name).Err(err).Msg("generation failed")
+				return
+			}
+			log.Info().Int("done", len(generated)).Int("total", len(reqs)).Str("contact", req.Contact.Name).Str("subject", email.Subject).Msg("email generated")
+		})
+		if runErr != nil {
+			log.Warn().Err(runErr).Int("done", len(generated)).Int("total", len(reqs)).Msg("generation interrupted, writing partial results")
+		}
+
+		var emails []models.Email
+		if generateRetryFailed {
+			emails = existing
+			for i := range emails {
+				if e, ok := generated[strings.ToLower(emails[i].Contact.Email)]; ok {
+					emails[i] = *e
+				}
+			}
+		} else {
+			for _, c := range targets {
+				if e, ok := generated[strings.ToLower(c.Email)]; ok {
+					emails = append(emails, *e)
+				}
 			}
-			emails = append(emails, *email)
-			log.Info().Str("contact", c.Name).Str("subject", email.Subject).Msg("email generated")
 		}
 
-		if err := output.WriteEmails(cfg.Output.Path, emails); err != nil {
+		// A run over a selection adds to the emails of earlier batches
+		if err := writeEmails(cfg.Output.Path, emails, sel.active() && !generateRetryFailed); err != nil {
 			return err
 		}
 
-		log.Info().Str("path", cfg.Output.Path).Int("count", len(emails)).Msg("emails written")
-		return nil
+		log.Info().Str("path", cfg.Output.Path).Int("count", len(emails)).Int("failed", failed).Msg("emails written")
+		return runErr
 	},
 }
 
+// newGenerator builds the configured LLM generator with its prompt templates.
+func newGenerator() (*generator.LLMGenerator, error) {
+	prompt, err := generator.LoadPrompt(cfg.Prompt)
+	if err != nil {
+		return nil, err
+	}
+	return generator.NewGenerator(cfg.LLM, prompt, cfg.Sender.Name)
+}
+
+// newResolver builds the fallback chain for company context: an earlier
+// cached scrape, the contact's fields, then web search if configured.
+func newResolver() (*enrich.Resolver, error) {
+	search, err := enrich.NewSearcher(cfg.Context.Search)
+	if err != nil {
+		return nil, err
+	}
+	res := &enrich.Resolver{Search: search}
+	if cfg.Cache.Enabled {
+		c := scrapeCache()
+		res.Previous = func(url string) (*models.ScrapeResult, time.Time) {
+			return scraper.Previous(c, cfg.Scraper, url)
+		}
+	}
+	return res, nil
+}
+
+// newRequest builds c's generation request, resolving company context
+// through res when the scrape has none.
+func newRequest(ctx context.Context, res *enrich.Resolver, c models.Contact, scrape *models.ScrapeResult, resumeText string) generator.Request {
+	scrape, source := res.Resolve(ctx, c, scrape)
+	return generator.Request{
+		Contact:       c,
+		Scrape:        scrape,
+		ContextSource: source,
+		ResumeText:    resumeText,
+		Links:         cfg.Sender.Links,
+	}
+}
+
 func init() {
 	generateCmd.Flags().StringVar(&generateScrapeInput, "scrape-input", "", "path to pre-scraped results JSON")
+	generateCmd.Flags().BoolVar(&generateRetryFailed, "retry-failed", false, "regenerate only entries whose generation failed in the existing output")
+	addSelectFlags(generateCmd)
+	addCampaignFlag(generateCmd)
 	rootCmd.AddCommand(generateCmd)
 }
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/dantezy/cold-send0r-bot/internal/inbox"
+	"github.com/dantezy/cold-send0r-bot/internal/ledger"
+	"github.com/dantezy/cold-send0r-bot/internal/output"
+)
+
+var (
+	inboxInput string
+	inboxDays  int
+)
+
+var inboxCmd = &cobra.Command{
+	Use:   "inbox",
+	Short: "Work with replies and bounces in your mailbox",
+}
+
+var inboxSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Match replies, auto-replies and bounces to sent emails over IMAP",
+	Long: "Connects to the configured IMAP mailbox, matches incoming messages to sent emails by Message-ID threading " +
+		"and sender address, and updates each email's status. Replied and bounced emails get no further follow-ups, " +
+		"and bounced addresses are suppressed in the send ledger.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if inboxInput == "" {
+			inboxInput = cfg.Output.Path
+		}
+		days := inboxDays
+		if days <= 0 {
+			days = cfg.IMAP.LookbackDays
+		}
+		if days <= 0 {
+			days = 30
+		}
+
+		emails, err := output.ReadEmails(inboxInput)
+		if err != nil {
+			return err
+		}
+
+		since := time.Now().AddDate(0, 0, -days)
+		log.Info().Str("host", cfg.IMAP.Host).Time("since", since).Msg("fetching mailbox")
+		raw, err := inbox.NewIMAPMailbox(cfg.IMAP).Messages(since)
+		if err != nil {
+			return err
+		}
+
+		var msgs []*inbox.Message
+		for _, r := range raw {
+			msg, err := inbox.Parse(r)
+			if err != nil {
+				log.Debug().Err(err).Msg("skipping unparseable message")
+				continue
+			}
+			msgs = append(msgs, msg)
+		}
+
+		res := inbox.Apply(emails, msgs)
+
+		if len(res.Bounced) > 0 {
+			led, err := ledger.Open(cfg.Ledger.Path)
+			if err != nil {
+				return err
+			}
+			defer led.Close()
+			for _, addr := range res.Bounced {
+				if err := led.Suppress(addr); err != nil {
+					log.Error().Str("email", addr).Err(err).Msg("failed to suppress bounced address")
+				}
+			}
+		}
+
+		if err := output.WriteEmails(inboxInput, emails); err != nil {
+			return err
+		}
+
+		log.Info().
+			Int("messages", len(msgs)).
+			Int("replies", res.Replies).
+			Int("auto_replies", res.AutoReplies).
+			Int("bounces", res.Bounces).
+			Int("unmatched", res.Unmatched).
+			Msg("inbox sync complete")
+		return nil
+	},
+}
+
+func init() {
+	inboxSyncCmd.Flags().StringVar(&inboxInput, "input", "", "path to emails JSON file (default: from config)")
+	inboxSyncCmd.Flags().IntVar(&inboxDays, "days", 0, "how many days back to scan (default: imap.lookback_days or 30)")
+	addCampaignFlag(inboxSyncCmd)
+	inboxCmd.AddCommand(inboxSyncCmd)
+	rootCmd.AddCommand(inboxCmd)
+}
@@ -2,12 +2,16 @@ package cmd
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/dantezy/cold-send0r-bot/internal/contacts"
 	"github.com/dantezy/cold-send0r-bot/internal/models"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
@@ -23,6 +27,27 @@ type otterStartup struct {
 	StartupTags      []otterTag      `json:"startup_tags"`
 }
 
+// fields keeps the startup's sector and tags on each of its contacts.
+func (s otterStartup) fields() map[string]string {
+	var tags []string
+	for _, t := range s.StartupTags {
+		if tag := strings.TrimSpace(t.Tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	fields := make(map[string]string)
+	if sector := strings.TrimSpace(s.Sector); sector != "" {
+		fields["sector"] = sector
+	}
+	if len(tags) > 0 {
+		fields["tags"] = strings.Join(tags, ", ")
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return fields
+}
+
 type otterEmployee struct {
 	Name  string `json:"name"`
 	Role  string `json:"role"`
@@ -36,12 +61,17 @@ type otterTag struct {
 var (
 	otterOutput string
 	otterApiKey string
+	otterForce  bool
 )
 
 var otterCmd = &cobra.Command{
 	Use:   "otter",
 	Short: "Import contacts from useotter.app",
-	Long:  "Fetches startup data from Otter and converts to contacts.json format.",
+	Long: `Fetches startup data from Otter and converts to contacts.json format.
+
+An existing output file is merged with the import: new people are added and
+blanks filled in, but values already in the file are kept. --force replaces
+the file instead.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if otterApiKey == "" {
 			otterApiKey = os.Getenv("OTTER_API_KEY")
@@ -76,7 +106,7 @@ var otterCmd = &cobra.Command{
 			return fmt.Errorf("parsing response: %w", err)
 		}
 
-		var contacts []models.Contact
+		var list []models.Contact
 		var skipped int
 		for _, s := range startups {
 			url := ""
@@ -88,29 +118,35 @@ var otterCmd = &cobra.Command{
 					skipped++
 					continue
 				}
-				contacts = append(contacts, models.Contact{
+				list = append(list, models.Contact{
 					Email:   emp.Email,
 					Name:    emp.Name,
 					Company: s.Name,
 					Role:    emp.Role,
 					URL:     url,
+					Fields:  s.fields(),
 				})
 			}
 		}
 
-		data, err := json.MarshalIndent(contacts, "", "  ")
-		if err != nil {
-			return fmt.Errorf("marshaling contacts: %w", err)
+		fetched := len(list)
+		var merges []contacts.Merge
+		if !otterForce {
+			if list, merges, err = mergeExisting(otterOutput, list); err != nil {
+				return err
+			}
 		}
 
-		if err := os.WriteFile(otterOutput, data, 0644); err != nil {
-			return fmt.Errorf("writing %s: %w", otterOutput, err)
+		if err := contacts.Write(otterOutput, list); err != nil {
+			return err
 		}
 
 		log.Info().
 			Int("startups", len(startups)).
-			Int("contacts", len(contacts)).
+			Int("contacts", fetched).
 			Int("skipped_no_email", skipped).
+			Int("merged", len(merges)).
+			Int("total", len(list)).
 			Str("output", otterOutput).
 			Msg("import complete")
 
@@ -118,8 +154,41 @@ var otterCmd = &cobra.Command{
 	},
 }
 
+// mergeExisting folds fetched into the contacts already at path. Values
+// in the file win conflicts, so hand edits survive a re-import; Otter
+// fills in blanks and adds new people. A file with rows Read rejects is
+// left alone rather than rewritten without them.
+func mergeExisting(path string, fetched []models.Contact) ([]models.Contact, []contacts.Merge, error) {
+	recs, rejected, err := contacts.Read(path, nil)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fetched, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	// Rewriting the file would lose rows Read could not parse
+	for _, r := range rejected {
+		log.Warn().Str("file", path).Int("row", r.Row).Str("reason", r.Reason).Msg("unreadable row")
+	}
+	if len(rejected) > 0 {
+		return nil, nil, fmt.Errorf("%s has %d unreadable rows: fix them or pass --force to replace the file", path, len(rejected))
+	}
+	list := make([]models.Contact, 0, len(recs)+len(fetched))
+	for _, r := range recs {
+		list = append(list, r.Contact)
+	}
+	list, merges := contacts.Dedupe(append(list, fetched...), contacts.MergeOptions{Policy: contacts.KeepFirst})
+	for _, m := range merges {
+		if len(m.Conflicts) > 0 {
+			log.Debug().Str("email", m.Email).Str("merge", m.Summary()).Msg("kept existing values")
+		}
+	}
+	return list, merges, nil
+}
+
 func init() {
 	otterCmd.Flags().StringVarP(&otterOutput, "output", "o", "contacts.json", "output file path")
+	otterCmd.Flags().BoolVar(&otterForce, "force", false, "replace the output file instead of merging into it")
 	otterCmd.Flags().StringVar(&otterApiKey, "apikey", "", "otter supabase apikey (or set OTTER_API_KEY env)")
 	rootCmd.AddCommand(otterCmd)
 }
@@ -1,122 +1,331 @@
 package cmd
 
 import (
+	"errors"
+	"io/fs"
+
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 
-	"github.com/dantezy/cold-send0r-bot/internal/contacts"
 	"github.com/dantezy/cold-send0r-bot/internal/generator"
+	"github.com/dantezy/cold-send0r-bot/internal/ledger"
 	"github.com/dantezy/cold-send0r-bot/internal/models"
 	"github.com/dantezy/cold-send0r-bot/internal/output"
 	"github.com/dantezy/cold-send0r-bot/internal/resume"
 	"github.com/dantezy/cold-send0r-bot/internal/scraper"
-	"github.com/dantezy/cold-send0r-bot/internal/sender"
+	"github.com/dantezy/cold-send0r-bot/internal/store"
 )
 
 var (
-	pipelineDryRun bool
-	pipelineOutput string
+	pipelineDryRun  bool
+	pipelineOutput  string
+	pipelineResume  bool
+	pipelineNoCache bool
+
+	pipelineAllowResend []string
 )
 
 var pipelineCmd = &cobra.Command{
 	Use:   "pipeline",
 	Short: "Full pipeline: scrape -> generate -> optionally send",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		contactList, err := contacts.Load(cfg.Contacts.Path)
+		ctx := cmd.Context()
+
+		sel, err := newSelection()
+		if err != nil {
+			return err
+		}
+
+		led, err := ledger.Open(cfg.Ledger.Path)
+		if err != nil {
+			return err
+		}
+		defer led.Close()
+
+		contactList, err := loadContacts(led)
 		if err != nil {
 			return err
 		}
 
+		outPath := pipelineOutput
+		if outPath == "" {
+			outPath = cfg.Output.Path
+		}
+		status, err := sel.statuses(outPath, led)
+		if err != nil {
+			return err
+		}
+		contactList = screenContacts(ctx, sel.contacts(contactList, status))
+
 		resumeText, err := resume.ReadText(cfg.Resume.TextPath)
 		if err != nil {
 			log.Warn().Err(err).Msg("could not read resume, proceeding without it")
 			resumeText = ""
 		}
 
+		gen, err := newGenerator()
+		if err != nil {
+			return err
+		}
+		res, err := newResolver()
+		if err != nil {
+			return err
+		}
+
+		smtpSender, err := newSMTPSender(cfg.Resume.Attachments)
+		if err != nil {
+			return err
+		}
+
+		st, err := store.Open(cfg.Output.StatePath)
+		if err != nil {
+			return err
+		}
+		defer st.Close()
+
+		if pipelineResume {
+			if err := adoptReviews(st, outPath); err != nil {
+				return err
+			}
+		} else if err := st.Reset(); err != nil {
+			return err
+		}
+
+		// Register every contact so the state file reflects the whole run
+		for _, c := range contactList {
+			if _, ok := st.Get(c.Email); ok {
+				continue
+			}
+			if err := st.Put(store.Record{Contact: c, Stage: store.StagePending}); err != nil {
+				return err
+			}
+		}
+
 		// Scrape
-		s := scraper.NewScraper(cfg.Scraper)
 		scrapeResults := make(map[string]*models.ScrapeResult)
+		for _, rec := range st.Records() {
+			if rec.Scrape != nil {
+				scrapeResults[rec.Contact.URL] = rec.Scrape
+			}
+		}
 
-		// Deduplicate URLs before scraping
+		// Deduplicate URLs before scraping, skipping anything already scraped
 		var uniqueURLs []string
-		seen := make(map[string]bool)
+		byURL := make(map[string][]models.Contact)
 		for _, c := range contactList {
-			if c.URL != "" && !seen[c.URL] {
-				seen[c.URL] = true
+			rec, _ := st.Get(c.Email)
+			if rec.Email != nil || rec.Scrape != nil || c.URL == "" {
+				continue
+			}
+			if cached := scrapeResults[c.URL]; cached != nil {
+				rec.Scrape = cached
+				rec.Stage = store.StageScraped
+				if err := st.Put(rec); err != nil {
+					return err
+				}
+				continue
+			}
+			if byURL[c.URL] == nil {
 				uniqueURLs = append(uniqueURLs, c.URL)
 			}
+			byURL[c.URL] = append(byURL[c.URL], c)
 		}
 
-		log.Info().Int("urls", len(uniqueURLs)).Int("contacts", len(contactList)).Msg("scraping company websites")
-		for i, u := range uniqueURLs {
-			log.Info().Int("index", i+1).Int("total", len(uniqueURLs)).Str("url", u).Msg("scraping")
-			result, err := s.Scrape(u)
+		log.Info().Int("urls", len(uniqueURLs)).Int("contacts", len(contactList)).Int("concurrency", cfg.Scraper.Concurrency).Msg("scraping company websites")
+		scr := newScraper(pipelineNoCache)
+		defer func() {
+			if err := scr.Close(); err != nil {
+				log.Warn().Err(err).Msg("could not shut down scraper")
+			}
+		}()
+		pool := scraper.NewPool(scr, cfg.Scraper)
+		var putErr error
+		done := 0
+		runErr := pool.Run(ctx, uniqueURLs, func(u string, result *models.ScrapeResult, err error) {
+			done++
 			if err != nil {
 				log.Error().Str("url", u).Err(err).Msg("scrape failed")
-				continue
-			}
-			scrapeResults[u] = result
-			if result.Error != "" {
+				result = &models.ScrapeResult{URL: u, Error: err.Error()}
+			} else if result.Error != "" {
 				log.Warn().Str("url", u).Str("error", result.Error).Msg("scrape had issues")
 			} else {
-				log.Info().Str("url", u).Int("length", len(result.Markdown)).Msg("scraped")
+				log.Info().Int("done", done).Int("total", len(uniqueURLs)).Str("url", u).Int("length", len(result.Markdown)).Msg("scraped")
+			}
+
+			// Persist as results arrive so an interrupted run keeps them
+			for _, c := range byURL[u] {
+				rec, _ := st.Get(c.Email)
+				rec.Scrape = result
+				rec.Stage = store.StageScraped
+				if err := st.Put(rec); err != nil && putErr == nil {
+					putErr = err
+				}
 			}
+		})
+		if putErr != nil {
+			return putErr
+		}
+		if runErr != nil {
+			log.Warn().Int("done", done).Int("total", len(uniqueURLs)).Msg("interrupted while scraping, rerun with --resume to continue")
+			return exportState(st, outPath, sel.active(), runErr)
 		}
 
 		// Generate
-		gen := generator.NewGenerator(cfg.LLM, cfg.Sender.Name)
-		var emails []models.Email
-
-		log.Info().Int("count", len(contactList)).Str("model", cfg.LLM.Model).Msg("generating personalized emails")
-		for i, c := range contactList {
-			log.Info().Int("index", i+1).Int("total", len(contactList)).Str("contact", c.Name).Str("company", c.Company).Msg("generating email")
-			email, err := gen.Generate(c, scrapeResults[c.URL], resumeText, cfg.Sender.Links)
-			if err != nil {
-				log.Error().Str("contact", c.Name).Err(err).Msg("generation failed")
+		var reqs []generator.Request
+		for _, c := range contactList {
+			rec, _ := st.Get(c.Email)
+			if rec.Email != nil && rec.Email.Status != models.StatusGenerationFailed {
 				continue
 			}
-			emails = append(emails, *email)
-			log.Info().Str("contact", c.Name).Str("subject", email.Subject).Msg("email generated")
+			reqs = append(reqs, newRequest(ctx, res, rec.Contact, rec.Scrape, resumeText))
 		}
 
-		outPath := pipelineOutput
-		if outPath == "" {
-			outPath = cfg.Output.Path
+		log.Info().Int("count", len(reqs)).Int("concurrency", cfg.LLM.Concurrency).Str("model", cfg.LLM.Model).Msg("generating personalized emails")
+		genPool := generator.NewPool(gen, cfg.LLM)
+		done = 0
+		runErr = genPool.Run(ctx, reqs, func(req generator.Request, email *models.Email, err error) {
+			done++
+			rec, _ := st.Get(req.Contact.Email)
+			rec.Email = email
+			if err != nil {
+				log.Error().Str("contact", req.Contact.Name).Err(err).Msg("generation failed")
+				rec.Stage = store.StageFailed
+				rec.Error = err.Error()
+			} else {
+				log.Info().Int("done", done).Int("total", len(reqs)).Str("contact", req.Contact.Name).Str("subject", email.Subject).Msg("email generated")
+				rec.Stage = store.StageGenerated
+				rec.Error = ""
+			}
+			if err := st.Put(rec); err != nil && putErr == nil {
+				putErr = err
+			}
+		})
+		if putErr != nil {
+			return putErr
+		}
+		if runErr != nil {
+			log.Warn().Int("done", done).Int("total", len(reqs)).Msg("interrupted while generating, rerun with --resume to continue")
+			return exportState(st, outPath, sel.active(), runErr)
 		}
 
-		if err := output.WriteEmails(outPath, emails); err != nil {
+		emails := st.Emails()
+		if err := writeEmails(outPath, emails, sel.active()); err != nil {
 			return err
 		}
 		log.Info().Str("path", outPath).Int("count", len(emails)).Msg("emails written")
 
 		// Send (unless dry run)
 		if pipelineDryRun {
-			log.Info().Str("path", outPath).Msg("DRY RUN -- review output, then run: send0r send --input <path> --confirm")
+			log.Info().Str("path", outPath).Msg("DRY RUN -- approve drafts with: send0r review, then run: send0r send --confirm")
 			return nil
 		}
 
-		smtpSender := sender.NewSMTPSender(cfg.SMTP, cfg.Sender.Email, cfg.Sender.Name, cfg.Resume.Attachments)
-		var sent, failed int
-		for i := range emails {
-			log.Info().Int("index", i+1).Int("total", len(emails)).Str("to", emails[i].Contact.Email).Msg("sending")
-			if err := smtpSender.Send(&emails[i]); err != nil {
+		// A resumed state file can hold contacts outside the selection.
+		// As with send, only reviewed drafts go out; failed ones were
+		// approved before.
+		selected := make(map[string]bool, len(contactList))
+		for _, c := range contactList {
+			selected[store.Key(c.Email)] = true
+		}
+		var unsent []store.Record
+		var unapproved int
+		for _, rec := range st.Records() {
+			if !selected[store.Key(rec.Contact.Email)] || rec.Email == nil || rec.Stage == store.StageSent {
+				continue
+			}
+			switch rec.Email.Status {
+			case models.StatusApproved, models.StatusFailed:
+				unsent = append(unsent, rec)
+			case models.StatusDraft:
+				unapproved++
+			}
+		}
+		if unapproved > 0 {
+			log.Warn().Int("count", unapproved).Msg("drafts not yet approved, run send0r review then send0r pipeline --resume --dry-run=false")
+		}
+
+		allow := resendSet(pipelineAllowResend)
+
+		var sent, failed, skipped int
+		for i, rec := range unsent {
+			if ctx.Err() != nil {
+				log.Warn().Int("done", i).Int("total", len(unsent)).Msg("interrupted while sending, rerun with --resume to continue")
+				return exportState(st, outPath, sel.active(), ctx.Err())
+			}
+			log.Info().Int("index", i+1).Int("total", len(unsent)).Str("to", rec.Contact.Email).Msg("sending")
+			ok, err := deliver(smtpSender, led, rec.Email, allow)
+			switch {
+			case err != nil:
+				rec.Stage = store.StageFailed
+				rec.Error = err.Error()
 				failed++
+			case !ok:
+				skipped++
 				continue
+			default:
+				rec.Stage = store.StageSent
+				rec.Error = ""
+				sent++
+			}
+			if err := st.Put(rec); err != nil {
+				return err
 			}
-			sent++
 		}
 
-		if err := output.WriteEmails(outPath, emails); err != nil {
+		if err := writeEmails(outPath, st.Emails(), sel.active()); err != nil {
 			log.Error().Err(err).Msg("failed to update email statuses")
 		}
 
-		log.Info().Int("sent", sent).Int("failed", failed).Msg("pipeline complete")
+		log.Info().Int("sent", sent).Int("failed", failed).Int("skipped", skipped).Msg("pipeline complete")
 		return nil
 	},
 }
 
+// adoptReviews takes the review decisions and edits saved in the emails
+// file at path into the state, so a resumed run neither overwrites them
+// nor sends drafts that were not approved.
+func adoptReviews(st *store.Store, path string) error {
+	reviewed, err := output.ReadEmails(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, e := range reviewed {
+		rec, ok := st.Get(e.Contact.Email)
+		// A failed generation is retried, so the state's copy is newer
+		if !ok || rec.Email == nil || e.Status == models.StatusGenerationFailed {
+			continue
+		}
+		rec.Email = &e
+		if e.Status == models.StatusSent {
+			rec.Stage = store.StageSent
+		}
+		if err := st.Put(rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportState writes the emails generated so far and passes cause through,
+// so an interrupted run still leaves a reviewable emails.json behind. merge
+// is as for writeEmails.
+func exportState(st *store.Store, path string, merge bool, cause error) error {
+	if err := writeEmails(path, st.Emails(), merge); err != nil {
+		log.Error().Err(err).Msg("failed to write partial emails")
+	}
+	return cause
+}
+
 func init() {
 	pipelineCmd.Flags().BoolVar(&pipelineDryRun, "dry-run", true, "generate emails without sending (default: true)")
 	pipelineCmd.Flags().StringVarP(&pipelineOutput, "output", "o", "", "output path (default: from config)")
+	pipelineCmd.Flags().StringSliceVar(&pipelineAllowResend, "allow-resend", nil, "addresses that may be emailed again despite the send ledger")
+	pipelineCmd.Flags().BoolVar(&pipelineResume, "resume", false, "resume the previous run from the campaign state file")
+	pipelineCmd.Flags().BoolVar(&pipelineNoCache, "no-cache", false, "scrape every site again instead of using cached results")
+	addSelectFlags(pipelineCmd)
+	addCampaignFlag(pipelineCmd)
 	rootCmd.AddCommand(pipelineCmd)
 }
@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/dantezy/cold-send0r-bot/internal/models"
+	"github.com/dantezy/cold-send0r-bot/internal/resume"
+	"github.com/dantezy/cold-send0r-bot/internal/store"
+)
+
+var (
+	promptContact     string
+	promptScrapeInput string
+)
+
+var promptCmd = &cobra.Command{
+	Use:   "prompt",
+	Short: "Inspect the LLM prompt templates",
+}
+
+var promptRenderCmd = &cobra.Command{
+	Use:   "render",
+	Short: "Print the final prompt for one contact without calling the LLM",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if promptContact == "" {
+			return fmt.Errorf("--contact is required")
+		}
+
+		contactList, err := loadContacts(nil)
+		if err != nil {
+			return err
+		}
+
+		var contact *models.Contact
+		for i := range contactList {
+			if strings.EqualFold(contactList[i].Email, promptContact) {
+				contact = &contactList[i]
+				break
+			}
+		}
+		if contact == nil {
+			return fmt.Errorf("contact %s not found in %s", promptContact, cfg.Contacts.Path)
+		}
+
+		resumeText, err := resume.ReadText(cfg.Resume.TextPath)
+		if err != nil {
+			log.Warn().Err(err).Msg("could not read resume, proceeding without it")
+			resumeText = ""
+		}
+
+		scrapeResult, err := findScrape(*contact, promptScrapeInput)
+		if err != nil {
+			return err
+		}
+
+		gen, err := newGenerator()
+		if err != nil {
+			return err
+		}
+
+		res, err := newResolver()
+		if err != nil {
+			return err
+		}
+		system, user, err := gen.Messages(newRequest(cmd.Context(), res, *contact, scrapeResult, resumeText))
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("=== SYSTEM ===")
+		fmt.Println(system)
+		fmt.Println()
+		fmt.Println("=== USER ===")
+		fmt.Println(user)
+		return nil
+	},
+}
+
+// findScrape looks up a contact's scrape result in a scrape results file, or
+// in the pipeline state when no file is given. A missing result is not an
+// error; the prompt simply has no website content.
+func findScrape(c models.Contact, scrapeInput string) (*models.ScrapeResult, error) {
+	scrapes, err := loadScrapes(scrapeInput)
+	if err != nil {
+		return nil, err
+	}
+	return scrapes[c.URL], nil
+}
+
+// loadScrapes indexes scrape results by URL, from scrapeInput or else from
+// the pipeline state. Either source may be missing.
+func loadScrapes(scrapeInput string) (map[string]*models.ScrapeResult, error) {
+	scrapes := make(map[string]*models.ScrapeResult)
+	if scrapeInput != "" {
+		data, err := os.ReadFile(scrapeInput)
+		if err != nil {
+			return nil, fmt.Errorf("reading scrape results: %w", err)
+		}
+		var results []models.ScrapeResult
+		if err := json.Unmarshal(data, &results); err != nil {
+			return nil, fmt.Errorf("parsing scrape results: %w", err)
+		}
+		for i := range results {
+			scrapes[results[i].URL] = &results[i]
+		}
+		return scrapes, nil
+	}
+
+	if _, err := os.Stat(cfg.Output.StatePath); err != nil {
+		return scrapes, nil
+	}
+	st, err := store.Open(cfg.Output.StatePath)
+	if err != nil {
+		return nil, err
+	}
+	defer st.Close()
+
+	for _, rec := range st.Records() {
+		if rec.Scrape != nil {
+			scrapes[rec.Contact.URL] = rec.Scrape
+		}
+	}
+	return scrapes, nil
+}
+
+func init() {
+	promptRenderCmd.Flags().StringVar(&promptContact, "contact", "", "email address of the contact to render the prompt for")
+	promptRenderCmd.Flags().StringVar(&promptScrapeInput, "scrape-input", "", "path to pre-scraped results JSON (default: pipeline state)")
+	addCampaignFlag(promptRenderCmd)
+	promptCmd.AddCommand(promptRenderCmd)
+	rootCmd.AddCommand(promptCmd)
+}
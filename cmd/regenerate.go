@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/dantezy/cold-send0r-bot/internal/generator"
+	"github.com/dantezy/cold-send0r-bot/internal/models"
+	"github.com/dantezy/cold-send0r-bot/internal/output"
+	"github.com/dantezy/cold-send0r-bot/internal/resume"
+)
+
+var (
+	regenerateInput       string
+	regenerateScrapeInput string
+	regenerateContact     string
+	regenerateHint        string
+)
+
+var regenerateCmd = &cobra.Command{
+	Use:   "regenerate",
+	Short: "Rewrite one contact's draft with an instruction, keeping earlier versions",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if regenerateContact == "" {
+			return fmt.Errorf("--contact is required")
+		}
+		if regenerateInput == "" {
+			regenerateInput = cfg.Output.Path
+		}
+
+		emails, err := output.ReadEmails(regenerateInput)
+		if err != nil {
+			return err
+		}
+
+		var email *models.Email
+		for i := range emails {
+			if strings.EqualFold(emails[i].Contact.Email, regenerateContact) {
+				email = &emails[i]
+				break
+			}
+		}
+		if email == nil {
+			return fmt.Errorf("no email for %s in %s", regenerateContact, regenerateInput)
+		}
+		if email.SentAt != nil {
+			return fmt.Errorf("email to %s was already sent on %s", regenerateContact, email.SentAt.Format(time.DateOnly))
+		}
+
+		scrapeResult, err := findScrape(email.Contact, regenerateScrapeInput)
+		if err != nil {
+			return err
+		}
+
+		resumeText, err := resume.ReadText(cfg.Resume.TextPath)
+		if err != nil {
+			log.Warn().Err(err).Msg("could not read resume, proceeding without it")
+			resumeText = ""
+		}
+
+		gen, err := newGenerator()
+		if err != nil {
+			return err
+		}
+
+		res, err := newResolver()
+		if err != nil {
+			return err
+		}
+		req := newRequest(cmd.Context(), res, email.Contact, scrapeResult, resumeText)
+		req.Previous = email
+		req.Hint = regenerateHint
+		fresh, err := generator.NewPool(gen, cfg.LLM).Generate(cmd.Context(), req)
+		if err != nil {
+			return fmt.Errorf("regenerating email for %s: %w", regenerateContact, err)
+		}
+
+		email.Revise(fresh, regenerateHint)
+		if err := output.WriteEmails(regenerateInput, emails); err != nil {
+			return err
+		}
+
+		log.Info().
+			Str("contact", email.Contact.Email).
+			Str("subject", email.Subject).
+			Int("version", len(email.Versions)+1).
+			Msg("email regenerated, approve it with send0r review")
+		fmt.Printf("\nSubject: %s\n\n%s\n", email.Subject, email.Body)
+		return nil
+	},
+}
+
+func init() {
+	regenerateCmd.Flags().StringVar(&regenerateContact, "contact", "", "email address of the contact whose draft to rewrite")
+	regenerateCmd.Flags().StringVar(&regenerateHint, "hint", "", `instruction for the rewrite, e.g. "mention their Rust SDK"`)
+	regenerateCmd.Flags().StringVar(&regenerateInput, "input", "", "path to emails JSON file (default: from config)")
+	regenerateCmd.Flags().StringVar(&regenerateScrapeInput, "scrape-input", "", "path to pre-scraped results JSON (default: pipeline state)")
+	addCampaignFlag(regenerateCmd)
+	rootCmd.AddCommand(regenerateCmd)
+}
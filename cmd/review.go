@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/dantezy/cold-send0r-bot/internal/generator"
+	"github.com/dantezy/cold-send0r-bot/internal/models"
+	"github.com/dantezy/cold-send0r-bot/internal/output"
+	"github.com/dantezy/cold-send0r-bot/internal/resume"
+	"github.com/dantezy/cold-send0r-bot/internal/review"
+)
+
+var (
+	reviewInput       string
+	reviewScrapeInput string
+	reviewAll         bool
+)
+
+var reviewCmd = &cobra.Command{
+	Use:   "review",
+	Short: "Approve, reject, edit or regenerate drafts before sending",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		if reviewInput == "" {
+			reviewInput = cfg.Output.Path
+		}
+
+		emails, err := output.ReadEmails(reviewInput)
+		if err != nil {
+			return err
+		}
+
+		var queue []int
+		for i, e := range emails {
+			switch e.Status {
+			case models.StatusDraft:
+				queue = append(queue, i)
+			case models.StatusApproved, models.StatusRejected:
+				if reviewAll {
+					queue = append(queue, i)
+				}
+			}
+		}
+		if len(queue) == 0 {
+			log.Info().Str("path", reviewInput).Msg("nothing to review")
+			return nil
+		}
+
+		scrapes, err := loadScrapes(reviewScrapeInput)
+		if err != nil {
+			return err
+		}
+
+		resumeText, err := resume.ReadText(cfg.Resume.TextPath)
+		if err != nil {
+			log.Warn().Err(err).Msg("could not read resume, proceeding without it")
+			resumeText = ""
+		}
+
+		gen, err := newGenerator()
+		if err != nil {
+			return err
+		}
+		res, err := newResolver()
+		if err != nil {
+			return err
+		}
+		pool := generator.NewPool(gen, cfg.LLM)
+
+		r := &review.Reviewer{
+			In:     os.Stdin,
+			Out:    os.Stdout,
+			Editor: review.Editor(),
+			Scrape: func(c models.Contact) *models.ScrapeResult {
+				return scrapes[c.URL]
+			},
+			Regenerate: func(ctx context.Context, email *models.Email, hint string) (*models.Email, error) {
+				req := newRequest(ctx, res, email.Contact, scrapes[email.Contact.URL], resumeText)
+				req.Previous = email
+				req.Hint = hint
+				return pool.Generate(ctx, req)
+			},
+			Save: func() error {
+				return output.WriteEmails(reviewInput, emails)
+			},
+		}
+
+		sum, err := r.Run(ctx, emails, queue)
+		log.Info().
+			Int("approved", sum.Approved).
+			Int("rejected", sum.Rejected).
+			Int("edited", sum.Edited).
+			Int("regenerated", sum.Regenerated).
+			Int("remaining", sum.Remaining).
+			Msg("review finished")
+		if err != nil && ctx.Err() == nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("review: %w", err)
+		}
+		if sum.Approved > 0 {
+			log.Info().Msg("send approved emails with: send0r send --confirm")
+		}
+		return nil
+	},
+}
+
+func init() {
+	reviewCmd.Flags().StringVar(&reviewInput, "input", "", "path to emails JSON file (default: from config)")
+	reviewCmd.Flags().StringVar(&reviewScrapeInput, "scrape-input", "", "path to pre-scraped results JSON (default: pipeline state)")
+	reviewCmd.Flags().BoolVar(&reviewAll, "all", false, "also revisit emails already approved or rejected")
+	addCampaignFlag(reviewCmd)
+	rootCmd.AddCommand(reviewCmd)
+}
@@ -1,7 +1,10 @@
 package cmd
 
 import (
+	"context"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/rs/zerolog"
@@ -12,9 +15,10 @@ import (
 )
 
 var (
-	cfgFile string
-	verbose bool
-	cfg     *config.Config
+	cfgFile  string
+	verbose  bool
+	campaign string
+	cfg      *config.Config
 )
 
 var rootCmd = &cobra.Command{
@@ -34,16 +38,31 @@ var rootCmd = &cobra.Command{
 		}
 
 		var err error
-		cfg, err = config.Load(cfgFile)
+		cfg, err = config.Load(cfgFile, campaign)
 		if err != nil {
 			return err
 		}
+		if cfg.Campaign != "" {
+			log.Info().Str("campaign", cfg.Campaign).Msg("using campaign")
+		}
 		return nil
 	},
 }
 
+// addCampaignFlag registers --campaign on commands that operate on a
+// campaign's contacts, prompt, sender and output.
+func addCampaignFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&campaign, "campaign", "", "named campaign from the campaigns section of the config")
+}
+
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	// Ctrl-C cancels the command context so long-running stages can stop
+	// in-flight work and still write partial results.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
+		stop()
 		os.Exit(1)
 	}
 }
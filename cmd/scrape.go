@@ -9,35 +9,77 @@ import (
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 
-	"github.com/dantezy/cold-send0r-bot/internal/contacts"
+	"github.com/dantezy/cold-send0r-bot/internal/cache"
 	"github.com/dantezy/cold-send0r-bot/internal/models"
 	"github.com/dantezy/cold-send0r-bot/internal/scraper"
 )
 
-var scrapeOutput string
+var (
+	scrapeOutput  string
+	scrapeNoCache bool
+)
 
 var scrapeCmd = &cobra.Command{
 	Use:   "scrape",
 	Short: "Scrape company websites from contacts list",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		contactList, err := contacts.Load(cfg.Contacts.Path)
+		sel, err := newSelection()
 		if err != nil {
 			return err
 		}
 
-		s := scraper.NewScraper(cfg.Scraper)
-		var results []models.ScrapeResult
+		led := openLedger()
+		if led != nil {
+			defer led.Close()
+		}
+
+		contactList, err := loadContacts(led)
+		if err != nil {
+			return err
+		}
+		status, err := sel.statuses(cfg.Output.Path, led)
+		if err != nil {
+			return err
+		}
+		contactList = sel.contacts(contactList, status)
+
+		var urls []string
+		seen := make(map[string]bool)
+		for _, c := range contactList {
+			if !seen[c.URL] {
+				seen[c.URL] = true
+				urls = append(urls, c.URL)
+			}
+		}
 
-		for i, c := range contactList {
-			log.Info().Int("index", i+1).Int("total", len(contactList)).Str("url", c.URL).Msg("scraping")
-			result, err := s.Scrape(c.URL)
+		scr := newScraper(scrapeNoCache)
+		defer func() {
+			if err := scr.Close(); err != nil {
+				log.Warn().Err(err).Msg("could not shut down scraper")
+			}
+		}()
+		pool := scraper.NewPool(scr, cfg.Scraper)
+		byURL := make(map[string]models.ScrapeResult)
+
+		log.Info().Int("urls", len(urls)).Int("concurrency", cfg.Scraper.Concurrency).Msg("scraping company websites")
+		runErr := pool.Run(cmd.Context(), urls, func(u string, result *models.ScrapeResult, err error) {
 			if err != nil {
-				log.Error().Str("url", c.URL).Err(err).Msg("scrape failed")
-				results = append(results, models.ScrapeResult{URL: c.URL, Error: err.Error()})
-				continue
+				log.Error().Str("url", u).Err(err).Msg("scrape failed")
+				byURL[u] = models.ScrapeResult{URL: u, Error: err.Error()}
+				return
+			}
+			byURL[u] = *result
+			log.Info().Int("done", len(byURL)).Int("total", len(urls)).Str("url", u).Int("length", len(result.Markdown)).Msg("scraped")
+		})
+		if runErr != nil {
+			log.Warn().Err(runErr).Int("done", len(byURL)).Int("total", len(urls)).Msg("scrape interrupted, writing partial results")
+		}
+
+		var results []models.ScrapeResult
+		for _, c := range contactList {
+			if r, ok := byURL[c.URL]; ok {
+				results = append(results, r)
 			}
-			results = append(results, *result)
-			log.Info().Str("url", c.URL).Int("length", len(result.Markdown)).Msg("scraped")
 		}
 
 		data, err := json.MarshalIndent(results, "", "  ")
@@ -53,11 +95,29 @@ var scrapeCmd = &cobra.Command{
 		}
 
 		log.Info().Str("path", scrapeOutput).Int("count", len(results)).Msg("scrape results written")
-		return nil
+		return runErr
 	},
 }
 
+// newScraper builds the configured scraper, behind the disk cache when it
+// is enabled. noCache skips cached results but still refreshes the cache.
+func newScraper(noCache bool) scraper.Scraper {
+	return scraper.NewScraper(cfg.Scraper, openCache(noCache))
+}
+
+func openCache(refresh bool) *cache.Cache {
+	if !cfg.Cache.Enabled {
+		return nil
+	}
+	c := scrapeCache()
+	c.Refresh = refresh
+	return c
+}
+
 func init() {
 	scrapeCmd.Flags().StringVarP(&scrapeOutput, "output", "o", "output/scrape_results.json", "output path for scrape results")
+	scrapeCmd.Flags().BoolVar(&scrapeNoCache, "no-cache", false, "scrape every site again instead of using cached results")
+	addSelectFlags(scrapeCmd)
+	addCampaignFlag(scrapeCmd)
 	rootCmd.AddCommand(scrapeCmd)
 }
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/dantezy/cold-send0r-bot/internal/filter"
+	"github.com/dantezy/cold-send0r-bot/internal/ledger"
+	"github.com/dantezy/cold-send0r-bot/internal/models"
+	"github.com/dantezy/cold-send0r-bot/internal/output"
+)
+
+// statusNew is the status --where sees for a contact with no email yet.
+const statusNew = "new"
+
+var (
+	selectWhere  string
+	selectLimit  int
+	selectOffset int
+)
+
+// addSelectFlags registers --where, --limit and --offset on commands that
+// can work on part of the contact list.
+func addSelectFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&selectWhere, "where", "", `only contacts matching this expression, e.g. 'company ~ "AI" and status = new'`)
+	cmd.Flags().IntVar(&selectLimit, "limit", 0, "process at most this many matching contacts (0 for no limit)")
+	cmd.Flags().IntVar(&selectOffset, "offset", 0, "skip this many matching contacts first")
+}
+
+// selection is the part of the contact list chosen with --where, --offset
+// and --limit, in list order.
+type selection struct {
+	where  *filter.Filter
+	limit  int
+	offset int
+}
+
+func newSelection() (*selection, error) {
+	if selectLimit < 0 || selectOffset < 0 {
+		return nil, fmt.Errorf("--limit and --offset cannot be negative")
+	}
+	where, err := filter.Parse(selectWhere)
+	if err != nil {
+		return nil, fmt.Errorf("parsing --where: %w", err)
+	}
+	return &selection{where: where, limit: selectLimit, offset: selectOffset}, nil
+}
+
+// active reports whether any of the flags narrows the list.
+func (s *selection) active() bool {
+	return s.where != nil || s.limit > 0 || s.offset > 0
+}
+
+func (s *selection) match(c models.Contact, status string) bool {
+	return s.where.Match(filter.Contact(c, status))
+}
+
+// window returns the bounds of the --offset/--limit window over n matches.
+func (s *selection) window(n int) (start, end int) {
+	start = min(s.offset, n)
+	end = n
+	if s.limit > 0 {
+		end = min(start+s.limit, n)
+	}
+	return start, end
+}
+
+// contacts returns the selected contacts. status gives the email status
+// the expression sees for each contact.
+func (s *selection) contacts(list []models.Contact, status func(addr string) string) []models.Contact {
+	if !s.active() {
+		return list
+	}
+	var matched []models.Contact
+	for _, c := range list {
+		if s.match(c, status(c.Email)) {
+			matched = append(matched, c)
+		}
+	}
+	start, end := s.window(len(matched))
+	log.Info().Str("where", s.where.String()).Int("matched", len(matched)).Int("selected", end-start).Int("total", len(list)).Msg("contacts selected")
+	return matched[start:end]
+}
+
+// statuses returns each contact's status for --where: its email's status
+// in the emails file at path, "sent" when the ledger shows it was already
+// contacted, and "new" otherwise. The file is only read when the
+// expression mentions status.
+func (s *selection) statuses(path string, led *ledger.Ledger) (func(addr string) string, error) {
+	byEmail := make(map[string]string)
+	if s.where.Uses("status") {
+		emails, err := output.ReadEmails(path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		for _, e := range emails {
+			byEmail[strings.ToLower(e.Contact.Email)] = e.Status
+		}
+	}
+	return func(addr string) string {
+		if st := byEmail[strings.ToLower(addr)]; st != "" {
+			return st
+		}
+		if led != nil {
+			if _, ok := led.Contacted(addr); ok {
+				return models.StatusSent
+			}
+		}
+		return statusNew
+	}, nil
+}
+
+// writeEmails writes emails to path. With merge, they are laid over the
+// emails already in the file, replacing those for the same contact and
+// appending the rest, so a run over a selection keeps earlier batches.
+// Either way, emails already sent stay in the file and are not replaced by
+// a new draft: follow-ups and inbox sync need their thread.
+func writeEmails(path string, emails []models.Email, merge bool) error {
+	existing, err := output.ReadEmails(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if !merge {
+		var sent []models.Email
+		for _, e := range existing {
+			if e.SentAt != nil {
+				sent = append(sent, e)
+			}
+		}
+		existing = sent
+	}
+	index := make(map[string]int, len(existing))
+	for i, e := range existing {
+		index[strings.ToLower(e.Contact.Email)] = i
+	}
+	for _, e := range emails {
+		key := strings.ToLower(e.Contact.Email)
+		if i, ok := index[key]; ok {
+			if existing[i].SentAt != nil && e.SentAt == nil {
+				log.Warn().Str("email", e.Contact.Email).Msg("keeping the sent email instead of the new draft")
+				continue
+			}
+			existing[i] = e
+			continue
+		}
+		index[key] = len(existing)
+		existing = append(existing, e)
+	}
+	return output.WriteEmails(path, existing)
+}
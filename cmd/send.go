@@ -2,25 +2,37 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 
+	"github.com/dantezy/cold-send0r-bot/internal/ledger"
+	"github.com/dantezy/cold-send0r-bot/internal/models"
 	"github.com/dantezy/cold-send0r-bot/internal/output"
 	"github.com/dantezy/cold-send0r-bot/internal/sender"
 )
 
 var (
-	sendInput   string
-	sendConfirm bool
+	sendInput       string
+	sendConfirm     bool
+	sendAllowResend []string
 )
 
 var sendCmd = &cobra.Command{
 	Use:   "send",
-	Short: "Send emails from a generated emails JSON file",
+	Short: "Send approved emails from a generated emails JSON file",
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if sendInput == "" {
+			sendInput = cfg.Output.Path
+		}
 		if !sendConfirm {
-			return fmt.Errorf("you must pass --confirm to actually send emails. Review %s first", sendInput)
+			return fmt.Errorf("you must pass --confirm to actually send emails. Approve drafts in %s with send0r review first", sendInput)
+		}
+
+		sel, err := newSelection()
+		if err != nil {
+			return err
 		}
 
 		emails, err := output.ReadEmails(sendInput)
@@ -28,29 +40,142 @@ var sendCmd = &cobra.Command{
 			return err
 		}
 
-		smtpSender := sender.NewSMTPSender(cfg.SMTP, cfg.Sender.Email, cfg.Sender.Name, cfg.Resume.Attachments)
+		led, err := ledger.Open(cfg.Ledger.Path)
+		if err != nil {
+			return err
+		}
+		defer led.Close()
 
-		var sent, failed int
-		for i := range emails {
-			log.Info().Int("index", i+1).Int("total", len(emails)).Str("to", emails[i].Contact.Email).Msg("sending")
-			if err := smtpSender.Send(&emails[i]); err != nil {
-				failed++
+		smtpSender, err := newSMTPSender(cfg.Resume.Attachments)
+		if err != nil {
+			return err
+		}
+		allow := resendSet(sendAllowResend)
+
+		// Only reviewed drafts go out; failed ones were approved before.
+		// --limit and --offset count sendable emails.
+		var queue []int
+		var unapproved int
+		for i, e := range emails {
+			if !sel.match(e.Contact, e.Status) {
 				continue
 			}
-			sent++
+			if e.Status != models.StatusApproved && e.Status != models.StatusFailed {
+				if e.Status == models.StatusDraft {
+					unapproved++
+				}
+				continue
+			}
+			queue = append(queue, i)
+		}
+		start, end := sel.window(len(queue))
+		queue = queue[start:end]
+
+		// Drafts generated before validation was enabled have no verdict yet
+		if cfg.Validation.Enabled {
+			pending := make([]models.Contact, len(queue))
+			for n, i := range queue {
+				pending[n] = emails[i].Contact
+			}
+			validateContacts(cmd.Context(), pending)
+			for n, i := range queue {
+				emails[i].Contact.Validation = pending[n].Validation
+			}
+		}
+
+		var sent, failed, skipped int
+		for n, i := range queue {
+			log.Info().Int("index", n+1).Int("total", len(queue)).Str("to", emails[i].Contact.Email).Msg("sending")
+			ok, err := deliver(smtpSender, led, &emails[i], allow)
+			switch {
+			case err != nil:
+				failed++
+			case !ok:
+				skipped++
+			default:
+				sent++
+			}
 		}
 
 		if err := output.WriteEmails(sendInput, emails); err != nil {
 			log.Error().Err(err).Msg("failed to update email statuses")
 		}
 
-		log.Info().Int("sent", sent).Int("failed", failed).Msg("send complete")
+		if unapproved > 0 {
+			log.Warn().Int("count", unapproved).Msg("drafts not yet approved, run send0r review to approve them")
+		}
+		log.Info().Int("sent", sent).Int("failed", failed).Int("skipped", skipped).Msg("send complete")
 		return nil
 	},
 }
 
+// deliver sends email unless its recipient's address failed validation or
+// the ledger shows it was already contacted, and records every successful
+// send in the ledger. It reports false with a nil error when the email was
+// skipped.
+func deliver(s *sender.SMTPSender, led *ledger.Ledger, email *models.Email, allow map[string]bool) (bool, error) {
+	to := email.Contact.Email
+	if blocked(email.Contact) {
+		v := email.Contact.Validation
+		log.Warn().Str("to", to).Str("verdict", v.Verdict).Strs("reasons", v.Reasons).Msg("skipping, address failed validation")
+		return false, nil
+	}
+	if e, ok := led.Suppressed(to); ok {
+		log.Warn().Str("to", to).Time("bounced_at", e.SentAt).Msg("skipping, address bounced before")
+		return false, nil
+	}
+	if !allow[strings.ToLower(to)] {
+		if err := led.Check(to, cfg.Ledger.BlockDomains); err != nil {
+			log.Warn().Str("to", to).Err(err).Msg("skipping, use --allow-resend to override")
+			return false, nil
+		}
+	}
+
+	if err := s.Send(email); err != nil {
+		return false, err
+	}
+
+	entry := ledger.Entry{Email: to, MessageID: email.MessageID, Subject: email.Subject, Campaign: cfg.Campaign}
+	if email.SentAt != nil {
+		entry.SentAt = *email.SentAt
+	}
+	if err := led.Record(entry); err != nil {
+		log.Error().Str("to", to).Err(err).Msg("failed to record send in ledger")
+	}
+	return true, nil
+}
+
+// newSMTPSender builds the configured SMTP sender with its body renderer.
+func newSMTPSender(attachments []string) (*sender.SMTPSender, error) {
+	body, err := sender.NewRenderer(cfg.Email, cfg.Sender)
+	if err != nil {
+		return nil, err
+	}
+	return sender.NewSMTPSender(cfg.SMTP, cfg.Sender.Email, cfg.Sender.Name, attachments, body), nil
+}
+
+func resendSet(addrs []string) map[string]bool {
+	set := make(map[string]bool, len(addrs))
+	for _, a := range addrs {
+		set[strings.ToLower(strings.TrimSpace(a))] = true
+	}
+	return set
+}
+
+func openLedger() *ledger.Ledger {
+	led, err := ledger.Open(cfg.Ledger.Path)
+	if err != nil {
+		log.Warn().Err(err).Msg("could not open send ledger")
+		return nil
+	}
+	return led
+}
+
 func init() {
-	sendCmd.Flags().StringVar(&sendInput, "input", "output/emails.json", "path to emails JSON file")
+	sendCmd.Flags().StringVar(&sendInput, "input", "", "path to emails JSON file (default: from config)")
 	sendCmd.Flags().BoolVar(&sendConfirm, "confirm", false, "confirm sending (required)")
+	sendCmd.Flags().StringSliceVar(&sendAllowResend, "allow-resend", nil, "addresses that may be emailed again despite the send ledger")
+	addSelectFlags(sendCmd)
+	addCampaignFlag(sendCmd)
 	rootCmd.AddCommand(sendCmd)
 }
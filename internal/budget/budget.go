@@ -0,0 +1,282 @@
+// Package budget fits scraped markdown into a token budget. The markdown is
+// split into sections, each section is scored with BM25 against terms from
+// the contact's role and the sender's resume, and the best sections are
+// packed in, keeping their original order.
+package budget
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// charsPerToken is the rough size of a token in English text. It only has
+// to be good enough for budgeting.
+const charsPerToken = 4
+
+// maxSectionTokens bounds a section; longer ones are split at paragraphs
+// so one huge page section cannot crowd everything else out.
+const maxSectionTokens = 300
+
+// minFillTokens is the smallest remainder worth filling with the start of
+// a section that does not fit whole.
+const minFillTokens = 40
+
+// BM25 parameters.
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+// Tokens estimates how many LLM tokens s takes.
+func Tokens(s string) int {
+	return (len(s) + charsPerToken - 1) / charsPerToken
+}
+
+// Truncate shortens s to at most maxBytes without splitting a UTF-8
+// character, preferring to cut at a paragraph, line, sentence or word
+// boundary in the last fifth of the allowance. maxBytes <= 0 means no limit.
+func Truncate(s string, maxBytes int) string {
+	if maxBytes <= 0 || len(s) <= maxBytes {
+		return s
+	}
+	cut := maxBytes
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	s = s[:cut]
+
+	floor := cut * 4 / 5
+	for _, sep := range []string{"\n\n", "\n", ". ", " "} {
+		if i := strings.LastIndex(s, sep); i >= floor && i > 0 {
+			// Keep the full stop of a sentence boundary
+			return strings.TrimRight(s[:i+len(strings.TrimSpace(sep))], " \n")
+		}
+	}
+	return s
+}
+
+// Query is a weighted set of terms to rank sections by.
+type Query map[string]float64
+
+// NewQuery builds a query from the contact's role, weighted highest, and
+// the most frequent terms of the resume.
+func NewQuery(role, resume string) Query {
+	q := make(Query)
+	for _, t := range terms(role) {
+		q[t] += 3
+	}
+
+	counts := make(map[string]int)
+	for _, t := range terms(resume) {
+		counts[t]++
+	}
+	resumeTerms := make([]string, 0, len(counts))
+	for t := range counts {
+		resumeTerms = append(resumeTerms, t)
+	}
+	sort.Slice(resumeTerms, func(i, j int) bool {
+		if counts[resumeTerms[i]] != counts[resumeTerms[j]] {
+			return counts[resumeTerms[i]] > counts[resumeTerms[j]]
+		}
+		return resumeTerms[i] < resumeTerms[j]
+	})
+	if len(resumeTerms) > 50 {
+		resumeTerms = resumeTerms[:50]
+	}
+	for _, t := range resumeTerms {
+		q[t]++
+	}
+
+	// What outreach usually hooks on, whatever the role
+	for _, t := range []string{"hiring", "careers", "jobs", "engineering", "mission", "product", "launch", "team"} {
+		q[t] += 0.5
+	}
+	return q
+}
+
+type section struct {
+	text   string
+	tokens int
+	terms  map[string]int
+	length int
+	score  float64
+}
+
+// Pack returns the parts of markdown most relevant to q that fit in
+// maxTokens, in document order. The opening section is always kept, since
+// it usually says what the company does. Markdown that already fits is
+// returned unchanged; maxTokens <= 0 means no limit.
+func Pack(markdown string, q Query, maxTokens int) string {
+	if maxTokens <= 0 || Tokens(markdown) <= maxTokens {
+		return markdown
+	}
+	secs := split(markdown)
+	if len(secs) == 0 {
+		return ""
+	}
+	score(secs, q)
+
+	order := make([]int, len(secs))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order[1:], func(i, j int) bool {
+		return secs[order[1+i]].score > secs[order[1+j]].score
+	})
+
+	chosen := make(map[int]string)
+	remaining := maxTokens
+	for _, i := range order {
+		s := secs[i]
+		switch {
+		case s.tokens <= remaining:
+			chosen[i] = s.text
+			remaining -= s.tokens
+		case remaining >= minFillTokens && (i == 0 || s.score > 0):
+			chosen[i] = Truncate(s.text, remaining*charsPerToken)
+			remaining = 0
+		}
+		if remaining == 0 {
+			break
+		}
+	}
+
+	var parts []string
+	for i := range secs {
+		if text, ok := chosen[i]; ok && text != "" {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// split breaks markdown at headings outside code fences, then splits
+// sections over maxSectionTokens at paragraph boundaries.
+func split(markdown string) []section {
+	var blocks []string
+	var cur strings.Builder
+	fenced := false
+	for _, line := range strings.Split(markdown, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fenced = !fenced
+		}
+		if !fenced && strings.HasPrefix(trimmed, "#") && cur.Len() > 0 {
+			blocks = append(blocks, cur.String())
+			cur.Reset()
+		}
+		cur.WriteString(line)
+		cur.WriteByte('\n')
+	}
+	blocks = append(blocks, cur.String())
+
+	var out []section
+	for _, block := range blocks {
+		for _, chunk := range chunks(strings.TrimSpace(block)) {
+			out = append(out, section{text: chunk, tokens: Tokens(chunk)})
+		}
+	}
+	return out
+}
+
+func chunks(block string) []string {
+	if block == "" {
+		return nil
+	}
+	if Tokens(block) <= maxSectionTokens {
+		return []string{block}
+	}
+	var out []string
+	var cur string
+	for _, para := range strings.Split(block, "\n\n") {
+		// A paragraph too long for any chunk is split below, starting in cur
+		if cur != "" && Tokens(cur)+Tokens(para) > maxSectionTokens && Tokens(para) <= maxSectionTokens {
+			out = append(out, cur)
+			cur = ""
+		}
+		if cur != "" {
+			cur += "\n\n"
+		}
+		cur += para
+		for Tokens(cur) > maxSectionTokens {
+			head := Truncate(cur, maxSectionTokens*charsPerToken)
+			if head == "" {
+				break
+			}
+			out = append(out, head)
+			cur = strings.TrimSpace(cur[len(head):])
+		}
+	}
+	if strings.TrimSpace(cur) != "" {
+		out = append(out, cur)
+	}
+	return out
+}
+
+// score sets each section's BM25 score for q, treating sections as the
+// document collection.
+func score(secs []section, q Query) {
+	df := make(map[string]int)
+	total := 0
+	for i := range secs {
+		s := &secs[i]
+		s.terms = make(map[string]int)
+		for _, t := range terms(s.text) {
+			s.terms[t]++
+			s.length++
+		}
+		for t := range s.terms {
+			df[t]++
+		}
+		total += s.length
+	}
+	avg := float64(total) / float64(len(secs))
+	if avg == 0 {
+		return
+	}
+
+	n := float64(len(secs))
+	for i := range secs {
+		s := &secs[i]
+		for t, weight := range q {
+			tf := float64(s.terms[t])
+			if tf == 0 {
+				continue
+			}
+			idf := math.Log(1 + (n-float64(df[t])+0.5)/(float64(df[t])+0.5))
+			s.score += weight * idf * tf * (k1 + 1) / (tf + k1*(1-b+b*float64(s.length)/avg))
+		}
+	}
+}
+
+// terms lowercases s and splits it into words, keeping "c++" and "c#"
+// whole and dropping stopwords and single characters.
+func terms(s string) []string {
+	var out []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '+' && r != '#'
+	}) {
+		if utf8.RuneCountInString(w) < 2 || strings.Trim(w, "+#") == "" || stopwords[w] {
+			continue
+		}
+		out = append(out, w)
+	}
+	return out
+}
+
+var stopwords = map[string]bool{}
+
+func init() {
+	for _, w := range strings.Fields(`a about above after again all also am an and any are as at be because
+		been before being below between both but by can could did do does doing down during each few for
+		from further had has have having he her here hers him his how i if in into is it its itself just
+		me more most my no nor not now of off on once only or other our ours out over own same she should
+		so some such than that the their theirs them then there these they this those through to too under
+		until up very was we were what when where which while who whom why will with would you your yours
+		us get got new one two use used using via per etc www http https com`) {
+		stopwords[w] = true
+	}
+}
@@ -0,0 +1,218 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Cache is an on-disk store of JSON values grouped in namespaces. Each entry
+// lives in its own file named by the SHA-256 of its key, so concurrent
+// writers of different keys never touch the same file and writes are atomic.
+type Cache struct {
+	dir string
+	ttl time.Duration
+
+	// Refresh makes every Get miss, so callers fetch fresh data and
+	// overwrite what is stored.
+	Refresh bool
+}
+
+type entry struct {
+	Key      string          `json:"key"`
+	StoredAt time.Time       `json:"stored_at"`
+	Value    json.RawMessage `json:"value"`
+}
+
+// Stats describes one namespace.
+type Stats struct {
+	Namespace string
+	Entries   int
+	Expired   int
+	Bytes     int64
+	Oldest    time.Time
+	Newest    time.Time
+}
+
+func New(dir string, ttl time.Duration) *Cache {
+	return &Cache{dir: dir, ttl: ttl}
+}
+
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// Fresh reports whether an entry stored at storedAt is within the TTL.
+func (c *Cache) Fresh(storedAt time.Time) bool {
+	return c.ttl <= 0 || time.Since(storedAt) < c.ttl
+}
+
+// Get decodes the entry for key into v and returns when it was stored.
+// Expired entries are still returned; check them with Fresh.
+func (c *Cache) Get(namespace, key string, v any) (time.Time, bool) {
+	if c.Refresh {
+		return time.Time{}, false
+	}
+	return c.Peek(namespace, key, v)
+}
+
+// Peek is Get ignoring Refresh, for reading old entries as a fallback.
+func (c *Cache) Peek(namespace, key string, v any) (time.Time, bool) {
+	data, err := os.ReadFile(c.path(namespace, key))
+	if err != nil {
+		return time.Time{}, false
+	}
+	var e entry
+	if err := json.Unmarshal(data, &e); err != nil || e.Key != key {
+		return time.Time{}, false
+	}
+	if err := json.Unmarshal(e.Value, v); err != nil {
+		return time.Time{}, false
+	}
+	return e.StoredAt, true
+}
+
+// Put stores v under key, replacing any existing entry.
+func (c *Cache) Put(namespace, key string, v any) error {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("marshaling cache entry: %w", err)
+	}
+	data, err := json.Marshal(entry{Key: key, StoredAt: time.Now(), Value: value})
+	if err != nil {
+		return fmt.Errorf("marshaling cache entry: %w", err)
+	}
+
+	path := c.path(namespace, key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating cache directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("creating cache entry: %w", err)
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("writing cache entry: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Stats summarizes every namespace, sorted by name.
+func (c *Cache) Stats() ([]Stats, error) {
+	byNS := make(map[string]*Stats)
+	err := c.walk(func(namespace, path string, e entry, size int64) error {
+		s := byNS[namespace]
+		if s == nil {
+			s = &Stats{Namespace: namespace}
+			byNS[namespace] = s
+		}
+		s.Entries++
+		s.Bytes += size
+		if !c.Fresh(e.StoredAt) {
+			s.Expired++
+		}
+		if s.Oldest.IsZero() || e.StoredAt.Before(s.Oldest) {
+			s.Oldest = e.StoredAt
+		}
+		if e.StoredAt.After(s.Newest) {
+			s.Newest = e.StoredAt
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]Stats, 0, len(byNS))
+	for _, s := range byNS {
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Namespace < out[j].Namespace })
+	return out, nil
+}
+
+// Purge deletes entries, or only expired ones when expiredOnly is set, and
+// returns how many were removed.
+func (c *Cache) Purge(expiredOnly bool) (int, error) {
+	removed := 0
+	err := c.walk(func(_, path string, e entry, _ int64) error {
+		if expiredOnly && c.Fresh(e.StoredAt) {
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("removing cache entry: %w", err)
+		}
+		removed++
+		return nil
+	})
+	return removed, err
+}
+
+// walk calls fn for every readable entry. Unreadable files are treated as
+// expired entries from the zero time so Purge clears them out.
+func (c *Cache) walk(fn func(namespace, path string, e entry, size int64) error) error {
+	err := filepath.WalkDir(c.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".json") {
+			return nil
+		}
+		rel, _ := filepath.Rel(c.dir, path)
+		namespace, _, _ := strings.Cut(filepath.ToSlash(rel), "/")
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		var e entry
+		if data, err := os.ReadFile(path); err == nil {
+			_ = json.Unmarshal(data, &e)
+		}
+		return fn(namespace, path, e, info.Size())
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (c *Cache) path(namespace, key string) string {
+	sum := sha256.Sum256([]byte(key))
+	name := hex.EncodeToString(sum[:])
+	return filepath.Join(c.dir, namespace, name[:2], name+".json")
+}
+
+// NormalizeURL returns the form of rawURL used in cache keys: no scheme,
+// lowercase host without "www.", default ports, fragment or trailing slash,
+// and sorted query parameters.
+func NormalizeURL(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Host == "" {
+		return strings.TrimSpace(rawURL)
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	if port := u.Port(); port != "" && port != "80" && port != "443" {
+		host += ":" + port
+	}
+	key := host + strings.TrimSuffix(u.EscapedPath(), "/")
+	if q := u.Query(); len(q) > 0 {
+		key += "?" + q.Encode()
+	}
+	return key
+}
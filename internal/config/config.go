@@ -3,19 +3,69 @@ package config
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/joho/godotenv"
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Sender   SenderConfig   `mapstructure:"sender"`
-	Resume   ResumeConfig   `mapstructure:"resume"`
-	Contacts ContactsConfig `mapstructure:"contacts"`
-	Scraper  ScraperConfig  `mapstructure:"scraper"`
-	LLM      LLMConfig      `mapstructure:"llm"`
-	SMTP     SMTPConfig     `mapstructure:"smtp"`
-	Output   OutputConfig   `mapstructure:"output"`
+	Sender     SenderConfig     `mapstructure:"sender"`
+	Resume     ResumeConfig     `mapstructure:"resume"`
+	Contacts   ContactsConfig   `mapstructure:"contacts"`
+	Scraper    ScraperConfig    `mapstructure:"scraper"`
+	LLM        LLMConfig        `mapstructure:"llm"`
+	SMTP       SMTPConfig       `mapstructure:"smtp"`
+	IMAP       IMAPConfig       `mapstructure:"imap"`
+	Output     OutputConfig     `mapstructure:"output"`
+	Ledger     LedgerConfig     `mapstructure:"ledger"`
+	Cache      CacheConfig      `mapstructure:"cache"`
+	Context    ContextConfig    `mapstructure:"context"`
+	Validation ValidationConfig `mapstructure:"validation"`
+	Prompt     PromptConfig     `mapstructure:"prompt"`
+	Followup   FollowupConfig   `mapstructure:"followups"`
+	Email      EmailConfig      `mapstructure:"email"`
+
+	Campaigns map[string]CampaignConfig `mapstructure:"campaigns"`
+	// Campaign is the name of the campaign applied by Load, if any.
+	Campaign string `mapstructure:"-"`
+}
+
+// CampaignConfig overrides parts of the top-level config for one outreach
+// track. Anything left unset falls back to the top-level value.
+type CampaignConfig struct {
+	Contacts    string          `mapstructure:"contacts"`
+	Prompt      *PromptConfig   `mapstructure:"prompt"`
+	Attachments []string        `mapstructure:"attachments"`
+	Sender      *SenderConfig   `mapstructure:"sender"`
+	Output      string          `mapstructure:"output"`
+	StatePath   string          `mapstructure:"state_path"`
+	Followup    *FollowupConfig `mapstructure:"followups"`
+	Email       *EmailConfig    `mapstructure:"email"`
+}
+
+// FollowupConfig defines the follow-up sequence sent after an unanswered
+// email. Steps are counted in business days from the original send.
+type FollowupConfig struct {
+	Steps    []FollowupStep `mapstructure:"steps"`
+	Template string         `mapstructure:"template"`
+}
+
+type FollowupStep struct {
+	AfterBusinessDays int `mapstructure:"after_business_days"`
+}
+
+// EmailConfig controls how message bodies are delivered. Format is "text"
+// (the default), "html" or "both" for multipart/alternative. The HTML
+// version is rendered from the plain-text body through HTMLTemplate, which
+// may use a "signature" partial from SignatureTemplate; empty paths use the
+// built-in templates.
+type EmailConfig struct {
+	Format            string `mapstructure:"format"`
+	HTMLTemplate      string `mapstructure:"html_template"`
+	SignatureTemplate string `mapstructure:"signature_template"`
 }
 
 type SenderConfig struct {
@@ -29,27 +79,70 @@ type ResumeConfig struct {
 	Attachments []string `mapstructure:"attachments"`
 }
 
+// ContactsConfig locates the contact list: JSON, JSON Lines, CSV, TSV or
+// XLSX by extension. Mapping assigns column headers to contact fields
+// ("email", "name", "first_name", "last_name", "company", "role", "url");
+// other targets become custom fields and "-" drops the column.
 type ContactsConfig struct {
-	Path string `mapstructure:"path"`
+	Path    string            `mapstructure:"path"`
+	Mapping map[string]string `mapstructure:"mapping"`
+	Dedupe  DedupeConfig      `mapstructure:"dedupe"`
 }
 
+// DedupeConfig controls how contacts listed more than once are merged.
+// Policy decides conflicting fields: "first" (default) keeps the earlier
+// record's value, "last" the later one's.
+type DedupeConfig struct {
+	Policy    string `mapstructure:"policy"`
+	FoldGmail bool   `mapstructure:"fold_gmail"`
+}
+
+// MinContentLength is the smallest scraper.max_content_length honored.
+// The cap only guards against huge pages; prompt.content_tokens decides
+// how much of a page the LLM sees.
+const MinContentLength = 100000
+
 type ScraperConfig struct {
-	Provider         string `mapstructure:"provider"`
-	RateLimitMs      int    `mapstructure:"rate_limit_ms"`
-	TimeoutMs        int    `mapstructure:"timeout_ms"`
-	MaxContentLength int    `mapstructure:"max_content_length"`
-	RodFallback      bool   `mapstructure:"rod_fallback"`
-	FirecrawlAPIKey  string `mapstructure:"-"`
+	Provider         string      `mapstructure:"provider"`
+	RateLimitMs      int         `mapstructure:"rate_limit_ms"`
+	Concurrency      int         `mapstructure:"concurrency"`
+	TimeoutMs        int         `mapstructure:"timeout_ms"`
+	MaxContentLength int         `mapstructure:"max_content_length"`
+	RodFallback      bool        `mapstructure:"rod_fallback"`
+	Crawl            CrawlConfig `mapstructure:"crawl"`
+	// UserAgent and Contact (sent as the From header) identify the
+	// scraper to site owners.
+	UserAgent           string   `mapstructure:"user_agent"`
+	Contact             string   `mapstructure:"contact"`
+	IgnoreRobots        bool     `mapstructure:"ignore_robots"`
+	RobotsIgnoreDomains []string `mapstructure:"robots_ignore_domains"`
+	FirecrawlAPIKey     string   `mapstructure:"-"`
+}
+
+// CrawlConfig enables following same-domain links such as about, careers
+// and blog pages from each contact's URL. MaxPages includes the start page;
+// MaxDepth counts link hops from it.
+type CrawlConfig struct {
+	Enabled  bool `mapstructure:"enabled"`
+	MaxPages int  `mapstructure:"max_pages"`
+	MaxDepth int  `mapstructure:"max_depth"`
 }
 
 type LLMConfig struct {
-	Provider   string  `mapstructure:"provider"`
-	APIKeyEnv  string  `mapstructure:"api_key_env"`
-	Model      string  `mapstructure:"model"`
-	Temperature float64 `mapstructure:"temperature"`
-	MaxTokens  int     `mapstructure:"max_tokens"`
-	RateLimitMs int    `mapstructure:"rate_limit_ms"`
-	APIKey     string  `mapstructure:"-"`
+	Provider          string  `mapstructure:"provider"`
+	APIKeyEnv         string  `mapstructure:"api_key_env"`
+	BaseURL           string  `mapstructure:"base_url"`
+	Model             string  `mapstructure:"model"`
+	Temperature       float64 `mapstructure:"temperature"`
+	MaxTokens         int     `mapstructure:"max_tokens"`
+	RateLimitMs       int     `mapstructure:"rate_limit_ms"`
+	Concurrency       int     `mapstructure:"concurrency"`
+	RequestsPerMinute int     `mapstructure:"requests_per_minute"`
+	TokensPerMinute   int     `mapstructure:"tokens_per_minute"`
+	MaxRetries        int     `mapstructure:"max_retries"`
+	RetryBaseMs       int     `mapstructure:"retry_base_ms"`
+	StructuredOutput  string  `mapstructure:"structured_output"`
+	APIKey            string  `mapstructure:"-"`
 }
 
 type SMTPConfig struct {
@@ -62,11 +155,79 @@ type SMTPConfig struct {
 	Password    string `mapstructure:"-"`
 }
 
+type IMAPConfig struct {
+	Host         string `mapstructure:"host"`
+	Port         int    `mapstructure:"port"`
+	UsernameEnv  string `mapstructure:"username_env"`
+	PasswordEnv  string `mapstructure:"password_env"`
+	Mailbox      string `mapstructure:"mailbox"`
+	Insecure     bool   `mapstructure:"insecure"`
+	LookbackDays int    `mapstructure:"lookback_days"`
+	Username     string `mapstructure:"-"`
+	Password     string `mapstructure:"-"`
+}
+
 type OutputConfig struct {
-	Path string `mapstructure:"path"`
+	Path      string `mapstructure:"path"`
+	StatePath string `mapstructure:"state_path"`
+}
+
+// PromptConfig points at text/template files for the LLM prompt. Empty
+// paths use the built-in templates. ContentTokens is the budget for scraped
+// content; the sections most relevant to the contact are packed into it.
+type PromptConfig struct {
+	SystemTemplate string            `mapstructure:"system_template"`
+	UserTemplate   string            `mapstructure:"user_template"`
+	Vars           map[string]string `mapstructure:"vars"`
+	ContentTokens  int               `mapstructure:"content_tokens"`
 }
 
-func Load(cfgFile string) (*Config, error) {
+// CacheConfig controls the on-disk scrape cache. Results are reused for
+// TTLHours; after that colly revalidates pages with ETag/Last-Modified.
+type CacheConfig struct {
+	Enabled  bool   `mapstructure:"enabled"`
+	Dir      string `mapstructure:"dir"`
+	TTLHours int    `mapstructure:"ttl_hours"`
+}
+
+// ContextConfig controls the fallbacks used when a contact's website could
+// not be scraped: an older cached scrape, the contact's own fields, then a
+// web search, which is off unless Search.Provider is set.
+type ContextConfig struct {
+	Search SearchConfig `mapstructure:"search"`
+}
+
+// SearchConfig selects the web search provider, "duckduckgo" (no key) or
+// "brave". BaseURL overrides the provider's endpoint.
+type SearchConfig struct {
+	Provider   string `mapstructure:"provider"`
+	APIKeyEnv  string `mapstructure:"api_key_env"`
+	BaseURL    string `mapstructure:"base_url"`
+	MaxResults int    `mapstructure:"max_results"`
+	TimeoutMs  int    `mapstructure:"timeout_ms"`
+	APIKey     string `mapstructure:"-"`
+}
+
+// ValidationConfig controls the checks of contact email addresses before
+// generating and sending. Contacts whose verdict is listed in Block get no
+// email and are never mailed.
+type ValidationConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// SkipDNS turns off the MX/A lookups, keeping the offline checks.
+	SkipDNS           bool     `mapstructure:"skip_dns"`
+	TimeoutMs         int      `mapstructure:"timeout_ms"`
+	Block             []string `mapstructure:"block"`
+	DisposableDomains []string `mapstructure:"disposable_domains"`
+}
+
+type LedgerConfig struct {
+	Path         string `mapstructure:"path"`
+	BlockDomains bool   `mapstructure:"block_domains"`
+}
+
+// Load reads the config file and, when campaign is non-empty, applies that
+// campaign's overrides.
+func Load(cfgFile, campaign string) (*Config, error) {
 	_ = godotenv.Load()
 
 	if cfgFile != "" {
@@ -86,13 +247,61 @@ func Load(cfgFile string) (*Config, error) {
 		return nil, fmt.Errorf("unmarshaling config: %w", err)
 	}
 
-	cfg.LLM.APIKey = os.Getenv(cfg.LLM.APIKeyEnv)
-	if cfg.LLM.APIKey == "" {
-		return nil, fmt.Errorf("environment variable %s is not set", cfg.LLM.APIKeyEnv)
+	// Local servers (Ollama, llama.cpp) need no key: leave api_key_env empty
+	if cfg.LLM.APIKeyEnv != "" {
+		cfg.LLM.APIKey = os.Getenv(cfg.LLM.APIKeyEnv)
+		if cfg.LLM.APIKey == "" {
+			return nil, fmt.Errorf("environment variable %s is not set", cfg.LLM.APIKeyEnv)
+		}
+	}
+
+	// The ledger and cache are shared by all campaigns, so resolve them
+	// before overrides
+	if cfg.Ledger.Path == "" {
+		cfg.Ledger.Path = filepath.Join(filepath.Dir(cfg.Output.Path), "ledger.jsonl")
+	}
+	if cfg.Cache.Dir == "" {
+		cfg.Cache.Dir = filepath.Join(filepath.Dir(cfg.Output.Path), "cache")
+	}
+	if cfg.Cache.TTLHours <= 0 {
+		cfg.Cache.TTLHours = 24
+	}
+
+	if campaign != "" {
+		if err := cfg.applyCampaign(campaign); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.Output.StatePath == "" {
+		cfg.Output.StatePath = filepath.Join(filepath.Dir(cfg.Output.Path), "state.jsonl")
+	}
+	if cfg.Prompt.ContentTokens <= 0 {
+		cfg.Prompt.ContentTokens = 1000
+	}
+	// Scraped pages are ranked and packed into prompt.content_tokens when the
+	// prompt is built; a small scrape cap would leave nothing to rank
+	if n := cfg.Scraper.MaxContentLength; n > 0 && n < MinContentLength {
+		cfg.Scraper.MaxContentLength = MinContentLength
+	}
+	switch cfg.Contacts.Dedupe.Policy {
+	case "":
+		cfg.Contacts.Dedupe.Policy = "first"
+	case "first", "last":
+	default:
+		return nil, fmt.Errorf("contacts.dedupe.policy must be first or last, not %q", cfg.Contacts.Dedupe.Policy)
+	}
+	if len(cfg.Validation.Block) == 0 {
+		cfg.Validation.Block = []string{"invalid"}
 	}
 
 	cfg.SMTP.Username = os.Getenv(cfg.SMTP.UsernameEnv)
 	cfg.SMTP.Password = os.Getenv(cfg.SMTP.PasswordEnv)
+	cfg.IMAP.Username = os.Getenv(cfg.IMAP.UsernameEnv)
+	cfg.IMAP.Password = os.Getenv(cfg.IMAP.PasswordEnv)
+	if cfg.Context.Search.APIKeyEnv != "" {
+		cfg.Context.Search.APIKey = os.Getenv(cfg.Context.Search.APIKeyEnv)
+	}
 
 	if cfg.Scraper.Provider == "firecrawl" {
 		cfg.Scraper.FirecrawlAPIKey = os.Getenv("FIRECRAWL_API_KEY")
@@ -103,3 +312,87 @@ func Load(cfgFile string) (*Config, error) {
 
 	return &cfg, nil
 }
+
+func (c *Config) applyCampaign(name string) error {
+	camp, ok := c.Campaigns[name]
+	if !ok {
+		var names []string
+		for n := range c.Campaigns {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unknown campaign %q (configured: %s)", name, strings.Join(names, ", "))
+	}
+	c.Campaign = name
+
+	if camp.Contacts != "" {
+		c.Contacts.Path = camp.Contacts
+	}
+	if camp.Attachments != nil {
+		c.Resume.Attachments = camp.Attachments
+	}
+	if camp.Output != "" {
+		// Each campaign keeps its own state next to its output
+		c.Output.Path = camp.Output
+		c.Output.StatePath = camp.StatePath
+	} else if camp.StatePath != "" {
+		c.Output.StatePath = camp.StatePath
+	}
+
+	if s := camp.Sender; s != nil {
+		if s.Name != "" {
+			c.Sender.Name = s.Name
+		}
+		if s.Email != "" {
+			c.Sender.Email = s.Email
+		}
+		if s.Links != nil {
+			c.Sender.Links = s.Links
+		}
+	}
+
+	if f := camp.Followup; f != nil {
+		if f.Steps != nil {
+			c.Followup.Steps = f.Steps
+		}
+		if f.Template != "" {
+			c.Followup.Template = f.Template
+		}
+	}
+
+	if e := camp.Email; e != nil {
+		if e.Format != "" {
+			c.Email.Format = e.Format
+		}
+		if e.HTMLTemplate != "" {
+			c.Email.HTMLTemplate = e.HTMLTemplate
+		}
+		if e.SignatureTemplate != "" {
+			c.Email.SignatureTemplate = e.SignatureTemplate
+		}
+	}
+
+	if p := camp.Prompt; p != nil {
+		if p.SystemTemplate != "" {
+			c.Prompt.SystemTemplate = p.SystemTemplate
+		}
+		if p.UserTemplate != "" {
+			c.Prompt.UserTemplate = p.UserTemplate
+		}
+		if p.ContentTokens > 0 {
+			c.Prompt.ContentTokens = p.ContentTokens
+		}
+		if len(p.Vars) > 0 {
+			vars := make(map[string]string, len(c.Prompt.Vars)+len(p.Vars))
+			for k, v := range c.Prompt.Vars {
+				vars[k] = v
+			}
+			for k, v := range p.Vars {
+				vars[k] = v
+			}
+			c.Prompt.Vars = vars
+		}
+	}
+
+	return nil
+}
@@ -1,41 +1,78 @@
 package contacts
 
 import (
-	"encoding/json"
 	"fmt"
 	"net/mail"
-	"os"
 	"strings"
 
+	"github.com/dantezy/cold-send0r-bot/internal/ledger"
 	"github.com/dantezy/cold-send0r-bot/internal/models"
 	"github.com/rs/zerolog/log"
 )
 
-func Load(path string) ([]models.Contact, error) {
-	data, err := os.ReadFile(path)
+type Options struct {
+	// Ledger, when set, is used to report contacts that were already emailed.
+	Ledger *ledger.Ledger
+	// Mapping assigns file columns to contact fields; see Read.
+	Mapping map[string]string
+	// Dedupe controls how a person listed more than once is merged.
+	Dedupe MergeOptions
+}
+
+// Load reads and validates the contacts in path, in any format Read
+// accepts. Invalid rows are logged and skipped, and duplicates are merged.
+func Load(path string, opts Options) ([]models.Contact, error) {
+	recs, rejected, err := Read(path, opts.Mapping)
 	if err != nil {
-		return nil, fmt.Errorf("reading contacts file: %w", err)
+		return nil, err
+	}
+	valid, invalid := Validate(recs)
+	rejected = append(rejected, invalid...)
+	for _, r := range rejected {
+		log.Warn().Int("row", r.Row).Str("email", r.Email).Str("reason", r.Reason).Msg("skipping invalid contact")
 	}
 
-	var raw []models.Contact
-	if err := json.Unmarshal(data, &raw); err != nil {
-		return nil, fmt.Errorf("parsing contacts JSON: %w", err)
+	valid, merges := Dedupe(valid, opts.Dedupe)
+	for _, m := range merges {
+		event := log.Info()
+		if len(m.Conflicts) > 0 {
+			event = log.Warn()
+		}
+		event.Str("email", m.Email).Str("merged", m.Summary()).Msg("merged duplicate contact")
 	}
 
-	var valid []models.Contact
-	var skipped int
+	event := log.Info().Int("valid", len(valid)).Int("skipped", len(rejected)).Int("merged", len(merges))
+	if opts.Ledger != nil {
+		event = event.Int("already_contacted", len(Contacted(valid, opts.Ledger)))
+	}
+	event.Msg("contacts loaded")
+	return valid, nil
+}
 
-	for i, c := range raw {
-		if err := validateContact(c); err != nil {
-			log.Warn().Int("index", i).Str("email", c.Email).Err(err).Msg("skipping invalid contact")
-			skipped++
+// Validate splits records into usable contacts and rejected rows.
+func Validate(recs []Record) ([]models.Contact, []Rejected) {
+	var valid []models.Contact
+	var rejected []Rejected
+	for _, r := range recs {
+		if err := validateContact(r.Contact); err != nil {
+			rejected = append(rejected, Rejected{Row: r.Row, Email: r.Contact.Email, Reason: err.Error()})
 			continue
 		}
-		valid = append(valid, c)
+		valid = append(valid, r.Contact)
 	}
+	return valid, rejected
+}
 
-	log.Info().Int("valid", len(valid)).Int("skipped", skipped).Msg("contacts loaded")
-	return valid, nil
+// Contacted returns the contacts that appear in the send ledger.
+func Contacted(list []models.Contact, l *ledger.Ledger) []models.Contact {
+	var out []models.Contact
+	for _, c := range list {
+		if e, ok := l.Contacted(c.Email); ok {
+			log.Debug().Str("email", c.Email).Time("sent_at", e.SentAt).Msg("contact already in send ledger")
+			out = append(out, c)
+		}
+	}
+	return out
 }
 
 func validateContact(c models.Contact) error {
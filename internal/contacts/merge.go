@@ -0,0 +1,184 @@
+package contacts
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/dantezy/cold-send0r-bot/internal/cache"
+	"github.com/dantezy/cold-send0r-bot/internal/models"
+)
+
+// Conflict policies: which value wins when two records of one person set
+// a field differently. Blank values never conflict; the other record
+// fills them in.
+const (
+	KeepFirst = "first"
+	KeepLast  = "last"
+)
+
+// MergeOptions controls how duplicates are found and combined.
+type MergeOptions struct {
+	// Policy is KeepFirst (the default) or KeepLast.
+	Policy string
+	// FoldGmail treats Gmail addresses that differ only in dots or a +tag,
+	// or in googlemail.com for gmail.com, as one person.
+	FoldGmail bool
+}
+
+// Conflict is a field two records of one person disagree on.
+type Conflict struct {
+	Field   string
+	Kept    string
+	Dropped string
+}
+
+// Merge reports records folded into one contact.
+type Merge struct {
+	// Email is the address of the merged contact.
+	Email string
+	// Duplicates counts the records folded into it.
+	Duplicates int
+	// Addresses lists spellings of the address other than Email.
+	Addresses []string
+	Conflicts []Conflict
+}
+
+// EmailKey is the form of addr duplicates are matched on: trimmed and
+// lowercased, and with opts.FoldGmail, the canonical Gmail mailbox.
+func EmailKey(addr string, opts MergeOptions) string {
+	key := strings.ToLower(strings.TrimSpace(addr))
+	if !opts.FoldGmail {
+		return key
+	}
+	at := strings.LastIndex(key, "@")
+	if at < 0 {
+		return key
+	}
+	local, domain := key[:at], key[at+1:]
+	if domain != "gmail.com" && domain != "googlemail.com" {
+		return key
+	}
+	local, _, _ = strings.Cut(local, "+")
+	return strings.ReplaceAll(local, ".", "") + "@gmail.com"
+}
+
+// Dedupe folds contacts with the same EmailKey into the first of them,
+// field by field under opts.Policy. The result keeps the order in which
+// each person first appears.
+func Dedupe(list []models.Contact, opts MergeOptions) ([]models.Contact, []Merge) {
+	var out []models.Contact
+	var merges []*Merge
+	index := make(map[string]int, len(list))
+	report := make(map[int]*Merge)
+
+	for _, c := range list {
+		key := EmailKey(c.Email, opts)
+		i, seen := index[key]
+		// Records without an address are left for Validate to reject
+		if !seen || key == "" {
+			if key != "" {
+				index[key] = len(out)
+			}
+			out = append(out, c)
+			continue
+		}
+
+		m := report[i]
+		if m == nil {
+			m = &Merge{}
+			report[i] = m
+			merges = append(merges, m)
+		}
+		m.Duplicates++
+		out[i] = combine(out[i], c, opts.Policy == KeepLast, m)
+		m.Email = out[i].Email
+	}
+
+	result := make([]Merge, 0, len(merges))
+	for _, m := range merges {
+		// Only spellings other than the kept address are worth reporting
+		m.Addresses = slices.DeleteFunc(m.Addresses, func(a string) bool { return a == m.Email })
+		result = append(result, *m)
+	}
+	return out, result
+}
+
+// combine merges b into a. With preferB, b's values win conflicts.
+func combine(a, b models.Contact, preferB bool, m *Merge) models.Contact {
+	if a.Email != b.Email {
+		for _, addr := range []string{a.Email, b.Email} {
+			if !slices.Contains(m.Addresses, addr) {
+				m.Addresses = append(m.Addresses, addr)
+			}
+		}
+		if preferB {
+			a.Email = b.Email
+		}
+	}
+
+	pick := func(field string, kept *string, other string, same func(x, y string) bool) {
+		x, y := strings.TrimSpace(*kept), strings.TrimSpace(other)
+		switch {
+		case y == "" || same(x, y):
+		case x == "":
+			*kept = other
+		case preferB:
+			m.Conflicts = append(m.Conflicts, Conflict{Field: field, Kept: y, Dropped: x})
+			*kept = other
+		default:
+			m.Conflicts = append(m.Conflicts, Conflict{Field: field, Kept: x, Dropped: y})
+		}
+	}
+	pick(FieldName, &a.Name, b.Name, strings.EqualFold)
+	pick(FieldCompany, &a.Company, b.Company, strings.EqualFold)
+	pick(FieldRole, &a.Role, b.Role, strings.EqualFold)
+	pick(FieldURL, &a.URL, b.URL, func(x, y string) bool {
+		return cache.NormalizeURL(x) == cache.NormalizeURL(y)
+	})
+
+	if len(b.Fields) > 0 {
+		fields := make(map[string]string, len(a.Fields)+len(b.Fields))
+		for k, v := range a.Fields {
+			fields[k] = v
+		}
+		for _, k := range sortedKeys(b.Fields) {
+			v := fields[k]
+			pick("fields."+k, &v, b.Fields[k], strings.EqualFold)
+			fields[k] = v
+		}
+		a.Fields = fields
+	}
+
+	// The most recent check is the one that counts
+	if b.Validation != nil && (a.Validation == nil || b.Validation.CheckedAt.After(a.Validation.CheckedAt)) {
+		a.Validation = b.Validation
+	}
+	return a
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Summary describes a merge in one line, e.g.
+// `1 duplicate; role: kept "CTO" over "Founder"`.
+func (m Merge) Summary() string {
+	parts := []string{fmt.Sprintf("%d duplicate", m.Duplicates)}
+	if m.Duplicates != 1 {
+		parts[0] += "s"
+	}
+	if len(m.Addresses) > 0 {
+		parts = append(parts, "also "+strings.Join(m.Addresses, ", "))
+	}
+	for _, c := range m.Conflicts {
+		parts = append(parts, fmt.Sprintf("%s: kept %q over %q", c.Field, c.Kept, c.Dropped))
+	}
+	return strings.Join(parts, "; ")
+}
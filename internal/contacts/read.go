@@ -0,0 +1,361 @@
+package contacts
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/dantezy/cold-send0r-bot/internal/models"
+)
+
+// Contact fields a column can map to. Any other target name is stored in
+// Contact.Fields under that key; "-" drops the column.
+const (
+	FieldEmail     = "email"
+	FieldName      = "name"
+	FieldFirstName = "first_name"
+	FieldLastName  = "last_name"
+	FieldCompany   = "company"
+	FieldRole      = "role"
+	FieldURL       = "url"
+	fieldSkip      = "-"
+)
+
+// aliases maps common spreadsheet and CRM headers, normalized by
+// headerKey, to contact fields.
+var aliases = map[string]string{
+	"email": FieldEmail, "emailaddress": FieldEmail, "workemail": FieldEmail, "businessemail": FieldEmail, "mail": FieldEmail,
+	"name": FieldName, "fullname": FieldName, "contactname": FieldName, "contact": FieldName,
+	"firstname": FieldFirstName, "givenname": FieldFirstName, "first": FieldFirstName,
+	"lastname": FieldLastName, "surname": FieldLastName, "familyname": FieldLastName, "last": FieldLastName,
+	"company": FieldCompany, "companyname": FieldCompany, "organization": FieldCompany, "organisation": FieldCompany,
+	"account": FieldCompany, "accountname": FieldCompany, "employer": FieldCompany,
+	"role": FieldRole, "title": FieldRole, "jobtitle": FieldRole, "position": FieldRole,
+	"url": FieldURL, "website": FieldURL, "companywebsite": FieldURL, "companyurl": FieldURL, "web": FieldURL,
+	"domain": FieldURL, "companydomain": FieldURL,
+}
+
+// Record is a contact read from a file, with the row it came from: the
+// spreadsheet row (the header is row 1), JSON Lines line, or 1-based
+// position in a JSON array.
+type Record struct {
+	Row     int
+	Contact models.Contact
+}
+
+// Rejected is a row that could not be used.
+type Rejected struct {
+	Row    int
+	Email  string
+	Reason string
+}
+
+// Read parses a contacts file by extension: .json, .jsonl/.ndjson, .csv,
+// .tsv or .xlsx. mapping assigns columns (or JSON keys), matched without
+// regard to case, to contact fields; unmapped spreadsheet columns with a
+// well-known header such as "Work Email" or "Website" are recognized, and
+// the rest go to Contact.Fields. JSON keys are read as they are. Rows are
+// not validated.
+func Read(path string, mapping map[string]string) ([]Record, []Rejected, error) {
+	m := make(map[string]string, len(mapping))
+	for k, v := range mapping {
+		m[strings.ToLower(strings.TrimSpace(k))] = strings.ToLower(strings.TrimSpace(v))
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".csv", ".tsv":
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading contacts file: %w", err)
+		}
+		defer f.Close()
+		r := csv.NewReader(f)
+		r.FieldsPerRecord = -1
+		r.LazyQuotes = true
+		if ext == ".tsv" {
+			r.Comma = '\t'
+		}
+		rows, err := r.ReadAll()
+		if err != nil {
+			return nil, nil, fmt.Errorf("parsing %s: %w", filepath.Base(path), err)
+		}
+		recs, rejected := fromTable(rows, m)
+		return recs, rejected, nil
+	case ".xlsx":
+		rows, err := readXLSX(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("parsing %s: %w", filepath.Base(path), err)
+		}
+		recs, rejected := fromTable(rows, m)
+		return recs, rejected, nil
+	case ".jsonl", ".ndjson":
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading contacts file: %w", err)
+		}
+		defer f.Close()
+		return fromJSONLines(f, m)
+	default:
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading contacts file: %w", err)
+		}
+		var objs []map[string]any
+		if err := json.Unmarshal(data, &objs); err != nil {
+			return nil, nil, fmt.Errorf("parsing contacts JSON: %w", err)
+		}
+		var recs []Record
+		for i, obj := range objs {
+			recs = append(recs, Record{Row: i + 1, Contact: fromObject(obj, m)})
+		}
+		return recs, nil, nil
+	}
+}
+
+func fromJSONLines(r io.Reader, mapping map[string]string) ([]Record, []Rejected, error) {
+	var recs []Record
+	var rejected []Rejected
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		text := bytes.TrimSpace(sc.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		var obj map[string]any
+		if err := json.Unmarshal(text, &obj); err != nil {
+			rejected = append(rejected, Rejected{Row: line, Reason: fmt.Sprintf("invalid JSON: %v", err)})
+			continue
+		}
+		recs = append(recs, Record{Row: line, Contact: fromObject(obj, mapping)})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, nil, fmt.Errorf("reading contacts file: %w", err)
+	}
+	return recs, rejected, nil
+}
+
+// fromTable maps rows under the header in rows[0]. Blank rows are skipped.
+func fromTable(rows [][]string, mapping map[string]string) ([]Record, []Rejected) {
+	// The header is the first non-blank row; spreadsheets often start
+	// with a title or an empty line
+	start := 0
+	for start < len(rows) && blank(rows[start]) {
+		start++
+	}
+	if start == len(rows) {
+		return nil, nil
+	}
+	header := rows[start]
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+	targets := make([]string, len(header))
+	for i, h := range header {
+		targets[i] = target(h, mapping)
+	}
+
+	var recs []Record
+	var rejected []Rejected
+	for i := start + 1; i < len(rows); i++ {
+		row := rows[i]
+		if blank(row) {
+			continue
+		}
+		if len(row) > len(header) {
+			rejected = append(rejected, Rejected{Row: i + 1, Reason: fmt.Sprintf("%d cells but only %d columns in the header", len(row), len(header))})
+			continue
+		}
+		var c contactBuilder
+		for j, v := range row {
+			c.set(targets[j], v)
+		}
+		recs = append(recs, Record{Row: i + 1, Contact: c.contact()})
+	}
+	return recs, rejected
+}
+
+// fromObject maps a JSON object's keys with objectTarget. A nested "fields"
+// object is merged into Contact.Fields, and a "validation" object written
+// by contacts validate is kept as is.
+func fromObject(obj map[string]any, mapping map[string]string) models.Contact {
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var c contactBuilder
+	for _, k := range keys {
+		nested, isObject := obj[k].(map[string]any)
+		switch {
+		case isObject && k == "fields":
+			for fk, fv := range nested {
+				c.set("fields."+fk, text(fv))
+			}
+		case isObject && k == "validation":
+			var v models.Validation
+			if data, err := json.Marshal(nested); err == nil && json.Unmarshal(data, &v) == nil && v.Verdict != "" {
+				c.c.Validation = &v
+			}
+		default:
+			c.set(objectTarget(k, mapping), text(obj[k]))
+		}
+	}
+	return c.contact()
+}
+
+// target resolves the field a column header maps to.
+func target(header string, mapping map[string]string) string {
+	if t, ok := mapping[strings.ToLower(strings.TrimSpace(header))]; ok {
+		return t
+	}
+	if t, ok := aliases[headerKey(header)]; ok {
+		return t
+	}
+	return fieldKey(header)
+}
+
+// objectTarget resolves the field a JSON key maps to. Keys are taken as
+// they are: a contact field's own name or an explicit mapping, and anything
+// else is a custom field under the same key. Spreadsheet aliases do not
+// apply, so a "title" or "domain" key stays a field.
+func objectTarget(key string, mapping map[string]string) string {
+	norm := strings.ToLower(strings.TrimSpace(key))
+	if t, ok := mapping[norm]; ok {
+		return t
+	}
+	switch norm {
+	case "":
+		return ""
+	case FieldEmail, FieldName, FieldFirstName, FieldLastName, FieldCompany, FieldRole, FieldURL:
+		return norm
+	}
+	return "fields." + key
+}
+
+// headerKey lowercases h and drops everything but letters and digits, so
+// "Work E-mail" and "work_email" match the same alias.
+func headerKey(h string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(h) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// fieldKey turns a header into a Fields key: "Funding Stage" becomes
+// "funding_stage".
+func fieldKey(h string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(h), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), "_")
+}
+
+type contactBuilder struct {
+	c           models.Contact
+	first, last string
+}
+
+func (b *contactBuilder) set(field, value string) {
+	value = strings.TrimSpace(value)
+	if value == "" || field == fieldSkip || field == "" {
+		return
+	}
+	switch field {
+	case FieldEmail:
+		b.c.Email = value
+	case FieldName:
+		b.c.Name = value
+	case FieldFirstName:
+		b.first = value
+	case FieldLastName:
+		b.last = value
+	case FieldCompany:
+		b.c.Company = value
+	case FieldRole:
+		b.c.Role = value
+	case FieldURL:
+		b.c.URL = value
+	default:
+		if b.c.Fields == nil {
+			b.c.Fields = make(map[string]string)
+		}
+		b.c.Fields[strings.TrimPrefix(field, "fields.")] = value
+	}
+}
+
+func (b *contactBuilder) contact() models.Contact {
+	c := b.c
+	if c.Name == "" {
+		c.Name = strings.TrimSpace(b.first + " " + b.last)
+	}
+	// Exports often hold a bare domain
+	if c.URL != "" && !strings.Contains(c.URL, "://") {
+		c.URL = "https://" + c.URL
+	}
+	return c
+}
+
+// text renders a JSON value as a cell: lists are joined with ", ".
+func text(v any) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case []any:
+		parts := make([]string, 0, len(t))
+		for _, item := range t {
+			if s := text(item); s != "" {
+				parts = append(parts, s)
+			}
+		}
+		return strings.Join(parts, ", ")
+	case map[string]any:
+		data, _ := json.Marshal(t)
+		return string(data)
+	default:
+		return fmt.Sprint(t)
+	}
+}
+
+func blank(row []string) bool {
+	for _, v := range row {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// Write saves contacts as an indented JSON array, the format Load reads by
+// default.
+func Write(path string, list []models.Contact) error {
+	if list == nil {
+		list = []models.Contact{}
+	}
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling contacts: %w", err)
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("creating contacts directory: %w", err)
+		}
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("writing contacts file: %w", err)
+	}
+	return nil
+}
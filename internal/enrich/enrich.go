@@ -0,0 +1,106 @@
+package enrich
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/dantezy/cold-send0r-bot/internal/models"
+)
+
+// Resolver finds company context for a contact whose website could not be
+// scraped, trying each source in turn from most to least specific.
+type Resolver struct {
+	// Previous returns an older successful scrape of url and when it was
+	// made, or nil.
+	Previous func(url string) (*models.ScrapeResult, time.Time)
+	// Search looks the company up on the web; nil skips this step.
+	Search Searcher
+}
+
+// Resolve returns the context to generate c's email from and its source,
+// one of the models.Context* constants. A scrape with content is used as
+// is. Fallback content replaces the markdown of a copy of scraped, so the
+// original error stays visible.
+func (r *Resolver) Resolve(ctx context.Context, c models.Contact, scraped *models.ScrapeResult) (*models.ScrapeResult, string) {
+	if scraped != nil && scraped.Markdown != "" {
+		return scraped, models.ContextScrape
+	}
+
+	if r.Previous != nil && c.URL != "" {
+		if prev, at := r.Previous(c.URL); prev != nil {
+			log.Info().Str("contact", c.Email).Str("url", c.URL).Time("scraped_at", at).Msg("no website content, using an earlier scrape")
+			return prev, models.ContextCache
+		}
+	}
+
+	if md := fieldsMarkdown(c); md != "" {
+		log.Info().Str("contact", c.Email).Msg("no website content, using contact fields")
+		return withMarkdown(c, scraped, md), models.ContextContact
+	}
+
+	if r.Search != nil && ctx.Err() == nil {
+		results, err := r.Search.Search(ctx, query(c))
+		if err != nil {
+			log.Warn().Str("contact", c.Email).Err(err).Msg("company search failed")
+		} else if md := resultsMarkdown(results); md != "" {
+			log.Info().Str("contact", c.Email).Int("results", len(results)).Msg("no website content, using search results")
+			return withMarkdown(c, scraped, md), models.ContextSearch
+		}
+	}
+
+	return scraped, models.ContextNone
+}
+
+func withMarkdown(c models.Contact, scraped *models.ScrapeResult, md string) *models.ScrapeResult {
+	out := &models.ScrapeResult{URL: c.URL}
+	if scraped != nil {
+		copied := *scraped
+		out = &copied
+	}
+	out.Markdown = md
+	out.Signals = nil
+	return out
+}
+
+// fieldsMarkdown lists the contact's fields as "- Label: value" lines.
+func fieldsMarkdown(c models.Contact) string {
+	var b strings.Builder
+	for _, f := range c.Facts() {
+		b.WriteString("- " + f + "\n")
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func resultsMarkdown(results []Result) string {
+	var b strings.Builder
+	for _, r := range results {
+		if r.Snippet == "" {
+			continue
+		}
+		b.WriteString("- ")
+		if r.Title != "" {
+			b.WriteString(r.Title + ": ")
+		}
+		b.WriteString(r.Snippet)
+		if r.URL != "" {
+			fmt.Fprintf(&b, " (%s)", r.URL)
+		}
+		b.WriteByte('\n')
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// query searches for the company name plus its domain, which tells apart
+// companies that share a name.
+func query(c models.Contact) string {
+	q := c.Company
+	if u, err := url.Parse(c.URL); err == nil && u.Hostname() != "" {
+		q += " " + strings.TrimPrefix(u.Hostname(), "www.")
+	}
+	return strings.TrimSpace(q)
+}
@@ -0,0 +1,186 @@
+package enrich
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dantezy/cold-send0r-bot/internal/config"
+)
+
+const (
+	duckDuckGoBaseURL = "https://api.duckduckgo.com/"
+	braveBaseURL      = "https://api.search.brave.com/res/v1/web/search"
+
+	defaultMaxResults = 5
+	defaultTimeout    = 10 * time.Second
+)
+
+// Searcher looks a query up on the web.
+type Searcher interface {
+	Search(ctx context.Context, query string) ([]Result, error)
+}
+
+// Result is one search hit.
+type Result struct {
+	Title   string
+	URL     string
+	Snippet string
+}
+
+// NewSearcher returns the configured search provider, or nil when none is.
+func NewSearcher(cfg config.SearchConfig) (Searcher, error) {
+	max := cfg.MaxResults
+	if max <= 0 {
+		max = defaultMaxResults
+	}
+	timeout := time.Duration(cfg.TimeoutMs) * time.Millisecond
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	client := &http.Client{Timeout: timeout}
+
+	switch cfg.Provider {
+	case "":
+		return nil, nil
+	case "duckduckgo":
+		return &duckDuckGo{client: client, baseURL: orDefault(cfg.BaseURL, duckDuckGoBaseURL), max: max}, nil
+	case "brave":
+		if cfg.APIKey == "" {
+			return nil, fmt.Errorf("brave search needs an API key: set context.search.api_key_env")
+		}
+		return &brave{client: client, baseURL: orDefault(cfg.BaseURL, braveBaseURL), apiKey: cfg.APIKey, max: max}, nil
+	default:
+		return nil, fmt.Errorf("unknown search provider %q", cfg.Provider)
+	}
+}
+
+// duckDuckGo uses the keyless Instant Answer API, which returns an abstract
+// and related topics rather than full web results.
+type duckDuckGo struct {
+	client  *http.Client
+	baseURL string
+	max     int
+}
+
+type ddgTopic struct {
+	Text     string     `json:"Text"`
+	FirstURL string     `json:"FirstURL"`
+	Topics   []ddgTopic `json:"Topics"`
+}
+
+type ddgResponse struct {
+	Heading       string     `json:"Heading"`
+	AbstractText  string     `json:"AbstractText"`
+	AbstractURL   string     `json:"AbstractURL"`
+	RelatedTopics []ddgTopic `json:"RelatedTopics"`
+}
+
+func (d *duckDuckGo) Search(ctx context.Context, query string) ([]Result, error) {
+	params := url.Values{"q": {query}, "format": {"json"}, "no_html": {"1"}, "skip_disambig": {"1"}}
+	var resp ddgResponse
+	if err := getJSON(ctx, d.client, d.baseURL+"?"+params.Encode(), nil, &resp); err != nil {
+		return nil, fmt.Errorf("duckduckgo search: %w", err)
+	}
+
+	var out []Result
+	if resp.AbstractText != "" {
+		out = append(out, Result{Title: resp.Heading, URL: resp.AbstractURL, Snippet: resp.AbstractText})
+	}
+	var walk func([]ddgTopic)
+	walk = func(topics []ddgTopic) {
+		for _, t := range topics {
+			if len(out) >= d.max {
+				return
+			}
+			if t.Text != "" {
+				out = append(out, Result{URL: t.FirstURL, Snippet: t.Text})
+			}
+			walk(t.Topics)
+		}
+	}
+	walk(resp.RelatedTopics)
+	return out, nil
+}
+
+type brave struct {
+	client  *http.Client
+	baseURL string
+	apiKey  string
+	max     int
+}
+
+type braveResponse struct {
+	Web struct {
+		Results []struct {
+			Title       string `json:"title"`
+			URL         string `json:"url"`
+			Description string `json:"description"`
+		} `json:"results"`
+	} `json:"web"`
+}
+
+func (b *brave) Search(ctx context.Context, query string) ([]Result, error) {
+	params := url.Values{"q": {query}, "count": {strconv.Itoa(b.max)}}
+	var resp braveResponse
+	if err := getJSON(ctx, b.client, b.baseURL+"?"+params.Encode(), map[string]string{"X-Subscription-Token": b.apiKey}, &resp); err != nil {
+		return nil, fmt.Errorf("brave search: %w", err)
+	}
+
+	var out []Result
+	for _, r := range resp.Web.Results {
+		out = append(out, Result{Title: stripTags(r.Title), URL: r.URL, Snippet: stripTags(r.Description)})
+	}
+	return out, nil
+}
+
+func getJSON(ctx context.Context, client *http.Client, rawURL string, headers map[string]string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	for k, val := range headers {
+		req.Header.Set(k, val)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("reading response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("parsing response: %w", err)
+	}
+	return nil
+}
+
+var tagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// stripTags removes the highlighting markup search APIs put in snippets.
+func stripTags(s string) string {
+	return strings.TrimSpace(html.UnescapeString(tagPattern.ReplaceAllString(s, "")))
+}
+
+func orDefault(v, def string) string {
+	if v != "" {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,444 @@
+// Package filter parses and evaluates the --where expressions used to pick
+// contacts, such as
+//
+//	company ~ "AI" and role in ["CTO", "Founder"] and not status = sent
+//
+// A comparison is a name, an operator and a value. Operators are = and !=
+// (equality), ~ and !~ (regular expression match), in and not in (a
+// [list]), and <, <=, > and >= (numeric when both sides are numbers).
+// Comparisons ignore case. Values are quoted strings or bare words, and
+// comparisons combine with and, or, not and parentheses.
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/dantezy/cold-send0r-bot/internal/models"
+)
+
+// Lookup returns the value of a name in an expression, or "" when it has
+// none.
+type Lookup func(name string) string
+
+// Filter is a parsed expression.
+type Filter struct {
+	src   string
+	root  node
+	names map[string]bool
+}
+
+// Parse parses expr. A blank expression yields a nil Filter, which matches
+// everything.
+func Parse(expr string) (*Filter, error) {
+	if strings.TrimSpace(expr) == "" {
+		return nil, nil
+	}
+	toks, err := lex(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks, names: make(map[string]bool)}
+	root, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, p.errorf(t, "unexpected %q", t.text)
+	}
+	return &Filter{src: expr, root: root, names: p.names}, nil
+}
+
+// Match reports whether the values from get satisfy the expression.
+func (f *Filter) Match(get Lookup) bool {
+	if f == nil {
+		return true
+	}
+	return f.root.eval(get)
+}
+
+// Uses reports whether the expression refers to name.
+func (f *Filter) Uses(name string) bool {
+	return f != nil && f.names[name]
+}
+
+func (f *Filter) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.src
+}
+
+// Contact looks names up on c: email, name, company, role and url, domain
+// for the email's domain, status for the given email status, verdict for
+// the address validation verdict, and anything else in c.Fields, with or
+// without a "fields." prefix.
+func Contact(c models.Contact, status string) Lookup {
+	return func(name string) string {
+		switch name {
+		case "email":
+			return c.Email
+		case "name":
+			return c.Name
+		case "company":
+			return c.Company
+		case "role":
+			return c.Role
+		case "url":
+			return c.URL
+		case "domain":
+			if i := strings.LastIndex(c.Email, "@"); i >= 0 {
+				return strings.ToLower(c.Email[i+1:])
+			}
+			return ""
+		case "status":
+			return status
+		case "verdict":
+			if c.Validation != nil {
+				return c.Validation.Verdict
+			}
+			return ""
+		}
+		name = strings.TrimPrefix(name, "fields.")
+		if v, ok := c.Fields[name]; ok {
+			return v
+		}
+		for k, v := range c.Fields {
+			if strings.EqualFold(k, name) {
+				return v
+			}
+		}
+		return ""
+	}
+}
+
+type node interface {
+	eval(get Lookup) bool
+}
+
+type andNode struct{ left, right node }
+
+func (n andNode) eval(get Lookup) bool { return n.left.eval(get) && n.right.eval(get) }
+
+type orNode struct{ left, right node }
+
+func (n orNode) eval(get Lookup) bool { return n.left.eval(get) || n.right.eval(get) }
+
+type notNode struct{ inner node }
+
+func (n notNode) eval(get Lookup) bool { return !n.inner.eval(get) }
+
+type compare struct {
+	name  string
+	op    string
+	value string
+	list  []string
+	re    *regexp.Regexp
+}
+
+func (c compare) eval(get Lookup) bool {
+	v := strings.TrimSpace(get(c.name))
+	switch c.op {
+	case "=":
+		return strings.EqualFold(v, c.value)
+	case "!=":
+		return !strings.EqualFold(v, c.value)
+	case "~":
+		return c.re.MatchString(v)
+	case "!~":
+		return !c.re.MatchString(v)
+	case "in", "not in":
+		found := false
+		for _, item := range c.list {
+			if strings.EqualFold(v, item) {
+				found = true
+				break
+			}
+		}
+		return found == (c.op == "in")
+	}
+
+	if v == "" {
+		return false
+	}
+	n := order(v, c.value)
+	switch c.op {
+	case "<":
+		return n < 0
+	case "<=":
+		return n <= 0
+	case ">":
+		return n > 0
+	default:
+		return n >= 0
+	}
+}
+
+// order compares a and b as numbers when both parse, and otherwise as
+// lowercased strings.
+func order(a, b string) int {
+	x, errA := strconv.ParseFloat(a, 64)
+	y, errB := strconv.ParseFloat(b, 64)
+	if errA == nil && errB == nil {
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
+
+type tokKind int
+
+const (
+	tokEOF tokKind = iota
+	tokWord
+	tokString
+	tokOp
+	tokPunct
+)
+
+type token struct {
+	kind tokKind
+	text string
+	pos  int
+}
+
+func lex(src string) ([]token, error) {
+	var toks []token
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(' || c == ')' || c == '[' || c == ']' || c == ',':
+			toks = append(toks, token{tokPunct, string(c), i})
+			i++
+		case c == '"' || c == '\'':
+			end := i + 1
+			for end < len(src) && src[end] != c {
+				if src[end] == '\\' && c == '"' {
+					end++
+				}
+				end++
+			}
+			if end >= len(src) {
+				return nil, fmt.Errorf("unterminated string at position %d", i+1)
+			}
+			text := src[i+1 : end]
+			if c == '"' {
+				s, err := strconv.Unquote(src[i : end+1])
+				if err != nil {
+					return nil, fmt.Errorf("invalid string at position %d: %w", i+1, err)
+				}
+				text = s
+			}
+			toks = append(toks, token{tokString, text, i})
+			i = end + 1
+		case strings.ContainsRune("=!~<>", rune(c)):
+			op := string(c)
+			if i+1 < len(src) && (src[i+1] == '=' || (c == '!' && src[i+1] == '~')) {
+				op += string(src[i+1])
+			}
+			if op == "!" {
+				return nil, fmt.Errorf("unexpected \"!\" at position %d, use not", i+1)
+			}
+			width := len(op)
+			if op == "==" {
+				op = "="
+			}
+			toks = append(toks, token{tokOp, op, i})
+			i += width
+		default:
+			end := i
+			for end < len(src) && isWordByte(src[end]) {
+				end++
+			}
+			if end == i {
+				return nil, fmt.Errorf("unexpected %q at position %d", rune(c), i+1)
+			}
+			toks = append(toks, token{tokWord, src[i:end], i})
+			i = end
+		}
+	}
+	return append(toks, token{tokEOF, "end of expression", len(src)}), nil
+}
+
+func isWordByte(b byte) bool {
+	return b >= 0x80 || unicode.IsLetter(rune(b)) || unicode.IsDigit(rune(b)) || strings.IndexByte("_.-@+:/", b) >= 0
+}
+
+type parser struct {
+	toks  []token
+	i     int
+	names map[string]bool
+}
+
+func (p *parser) peek() token { return p.toks[p.i] }
+
+func (p *parser) next() token {
+	t := p.toks[p.i]
+	if t.kind != tokEOF {
+		p.i++
+	}
+	return t
+}
+
+func (p *parser) keyword(word string) bool {
+	t := p.peek()
+	if t.kind == tokWord && strings.EqualFold(t.text, word) {
+		p.i++
+		return true
+	}
+	return false
+}
+
+func (p *parser) errorf(t token, format string, args ...any) error {
+	return fmt.Errorf("%s at position %d", fmt.Sprintf(format, args...), t.pos+1)
+}
+
+func (p *parser) or() (node, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) and() (node, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("and") {
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) unary() (node, error) {
+	if p.keyword("not") {
+		inner, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{inner}, nil
+	}
+	if t := p.peek(); t.kind == tokPunct && t.text == "(" {
+		p.next()
+		inner, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if t := p.next(); t.kind != tokPunct || t.text != ")" {
+			return nil, p.errorf(t, "expected \")\" but found %q", t.text)
+		}
+		return inner, nil
+	}
+	return p.comparison()
+}
+
+func (p *parser) comparison() (node, error) {
+	t := p.next()
+	if t.kind != tokWord || isKeyword(t.text) {
+		return nil, p.errorf(t, "expected a field name but found %q", t.text)
+	}
+	c := compare{name: strings.ToLower(t.text)}
+	p.names[c.name] = true
+
+	switch op := p.peek(); {
+	case op.kind == tokOp:
+		p.next()
+		c.op = op.text
+	case p.keyword("in"):
+		c.op = "in"
+	case p.keyword("not"):
+		if !p.keyword("in") {
+			return nil, p.errorf(p.peek(), "expected \"in\" after \"not\"")
+		}
+		c.op = "not in"
+	default:
+		return nil, p.errorf(op, "expected an operator after %q but found %q", t.text, op.text)
+	}
+
+	if c.op == "in" || c.op == "not in" {
+		list, err := p.list()
+		if err != nil {
+			return nil, err
+		}
+		c.list = list
+		return c, nil
+	}
+
+	v, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	c.value = v
+	if c.op == "~" || c.op == "!~" {
+		re, err := regexp.Compile("(?i)" + v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", v, err)
+		}
+		c.re = re
+	}
+	return c, nil
+}
+
+func (p *parser) list() ([]string, error) {
+	if t := p.next(); t.kind != tokPunct || t.text != "[" {
+		return nil, p.errorf(t, "expected \"[\" but found %q", t.text)
+	}
+	var list []string
+	for {
+		if t := p.peek(); t.kind == tokPunct && t.text == "]" && len(list) == 0 {
+			p.next()
+			return list, nil
+		}
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, v)
+		switch t := p.next(); {
+		case t.kind == tokPunct && t.text == ",":
+		case t.kind == tokPunct && t.text == "]":
+			return list, nil
+		default:
+			return nil, p.errorf(t, "expected \",\" or \"]\" but found %q", t.text)
+		}
+	}
+}
+
+func (p *parser) value() (string, error) {
+	t := p.next()
+	if t.kind == tokString || (t.kind == tokWord && !isKeyword(t.text)) {
+		return t.text, nil
+	}
+	return "", p.errorf(t, "expected a value but found %q", t.text)
+}
+
+func isKeyword(word string) bool {
+	switch strings.ToLower(word) {
+	case "and", "or", "not", "in":
+		return true
+	}
+	return false
+}
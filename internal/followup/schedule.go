@@ -0,0 +1,52 @@
+package followup
+
+import (
+	"time"
+
+	"github.com/dantezy/cold-send0r-bot/internal/config"
+	"github.com/dantezy/cold-send0r-bot/internal/models"
+)
+
+// Next returns the 1-based step that should be sent next for email, or 0
+// when nothing is due. Only emails still in "sent" status qualify; replies,
+// bounces and failures end the sequence. A step whose draft was generated but
+// not yet sent is still returned so it can be sent.
+func Next(email models.Email, steps []config.FollowupStep, now time.Time) int {
+	if email.Status != models.StatusSent || email.SentAt == nil || email.MessageID == "" {
+		return 0
+	}
+
+	sent := 0
+	for _, fu := range email.FollowUps {
+		if fu.SentAt != nil {
+			sent++
+		}
+	}
+	if sent >= len(steps) {
+		return 0
+	}
+
+	if BusinessDaysBetween(*email.SentAt, now) < steps[sent].AfterBusinessDays {
+		return 0
+	}
+	return sent + 1
+}
+
+// BusinessDaysBetween counts weekdays after from up to and including to.
+func BusinessDaysBetween(from, to time.Time) int {
+	from = truncateDay(from)
+	to = truncateDay(to)
+
+	days := 0
+	for d := from.AddDate(0, 0, 1); !d.After(to); d = d.AddDate(0, 0, 1) {
+		if wd := d.Weekday(); wd != time.Saturday && wd != time.Sunday {
+			days++
+		}
+	}
+	return days
+}
+
+func truncateDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,154 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dantezy/cold-send0r-bot/internal/config"
+)
+
+const (
+	anthropicBaseURL = "https://api.anthropic.com"
+	anthropicVersion = "2023-06-01"
+)
+
+// anthropicClient uses the Anthropic Messages API.
+type anthropicClient struct {
+	cfg     config.LLMConfig
+	baseURL string
+	client  *http.Client
+}
+
+func newAnthropicClient(cfg config.LLMConfig) *anthropicClient {
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = anthropicBaseURL
+	}
+	return &anthropicClient{
+		cfg:     cfg,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// emailTool is the tool Claude is forced to call when structured output is
+// requested; its input is the email object.
+const emailTool = "write_email"
+
+type anthropicRequest struct {
+	Model       string               `json:"model"`
+	System      string               `json:"system,omitempty"`
+	Messages    []message            `json:"messages"`
+	Temperature float64              `json:"temperature"`
+	MaxTokens   int                  `json:"max_tokens"`
+	Tools       []anthropicTool      `json:"tools,omitempty"`
+	ToolChoice  *anthropicToolChoice `json:"tool_choice,omitempty"`
+}
+
+type anthropicTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"input_schema"`
+}
+
+type anthropicToolChoice struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+type anthropicResponse struct {
+	Content []struct {
+		Type  string          `json:"type"`
+		Text  string          `json:"text"`
+		Name  string          `json:"name"`
+		Input json.RawMessage `json:"input"`
+	} `json:"content"`
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (g *anthropicClient) complete(ctx context.Context, system, user string) (string, error) {
+	return g.messages(ctx, anthropicRequest{System: system, Messages: []message{{Role: "user", Content: user}}})
+}
+
+// completeJSON forces a tool call whose input schema is the email schema,
+// which is how the Messages API constrains output shape.
+func (g *anthropicClient) completeJSON(ctx context.Context, system, user string, schema map[string]any) (string, error) {
+	return g.messages(ctx, anthropicRequest{
+		System:   system,
+		Messages: []message{{Role: "user", Content: user}},
+		Tools: []anthropicTool{{
+			Name:        emailTool,
+			Description: "Record the finished cold outreach email.",
+			InputSchema: schema,
+		}},
+		ToolChoice: &anthropicToolChoice{Type: "tool", Name: emailTool},
+	})
+}
+
+func (g *anthropicClient) messages(ctx context.Context, ar anthropicRequest) (string, error) {
+	ar.Model = g.cfg.Model
+	ar.Temperature = g.cfg.Temperature
+	ar.MaxTokens = g.cfg.MaxTokens
+
+	bodyBytes, err := json.Marshal(ar)
+	if err != nil {
+		return "", fmt.Errorf("marshaling request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.baseURL+"/v1/messages", bytes.NewReader(bodyBytes))
+	if err != nil {
+		return "", fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", g.cfg.APIKey)
+	req.Header.Set("anthropic-version", anthropicVersion)
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("calling anthropic: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("reading response: %w", err)
+	}
+
+	var aResp anthropicResponse
+	jsonErr := json.Unmarshal(respBody, &aResp)
+
+	if resp.StatusCode != http.StatusOK {
+		msg := string(respBody)
+		if jsonErr == nil && aResp.Error != nil {
+			msg = aResp.Error.Message
+		}
+		return "", newAPIError("anthropic", resp, msg)
+	}
+	if jsonErr != nil {
+		return "", fmt.Errorf("parsing response: %w", jsonErr)
+	}
+
+	var text strings.Builder
+	for _, block := range aResp.Content {
+		switch {
+		case block.Type == "tool_use" && block.Name == emailTool:
+			return string(block.Input), nil
+		case block.Type == "text":
+			text.WriteString(block.Text)
+		}
+	}
+	if text.Len() == 0 {
+		return "", fmt.Errorf("no text content in response")
+	}
+
+	return text.String(), nil
+}
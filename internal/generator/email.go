@@ -0,0 +1,191 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/dantezy/cold-send0r-bot/internal/config"
+	"github.com/dantezy/cold-send0r-bot/internal/models"
+)
+
+const textOutputInstructions = `
+
+Output exactly:
+SUBJECT: <subject line>
+BODY:
+<email body>`
+
+// chatClient sends one prompt to an LLM provider and returns the reply text.
+type chatClient interface {
+	complete(ctx context.Context, system, user string) (string, error)
+}
+
+// LLMGenerator is the provider-independent Generator: render the prompt,
+// ask the model, parse its reply into a draft. Providers that can constrain
+// output to a JSON schema are asked for structured output first; the
+// SUBJECT:/BODY: text format is the fallback.
+type LLMGenerator struct {
+	client     chatClient
+	cfg        config.LLMConfig
+	prompt     *Prompt
+	senderName string
+
+	// noSchema is set once the provider has rejected a structured request,
+	// so later drafts go straight to the text format.
+	noSchema atomic.Bool
+}
+
+func (g *LLMGenerator) structured() (structuredClient, bool) {
+	sc, ok := g.client.(structuredClient)
+	return sc, ok && g.cfg.StructuredOutput != "off" && !g.noSchema.Load()
+}
+
+// Messages returns the system and user prompt that Generate sends first,
+// including the output-format instructions.
+func (g *LLMGenerator) Messages(r Request) (system, user string, err error) {
+	system, user, err = g.render(r)
+	if err != nil {
+		return "", "", err
+	}
+	if _, ok := g.structured(); ok {
+		return system, user + jsonOutputInstructions, nil
+	}
+	return system, user + textOutputInstructions, nil
+}
+
+// render builds the prompt without output instructions. The previous draft
+// and reviewer hint are added outside the templates so they work with
+// custom ones too.
+func (g *LLMGenerator) render(r Request) (system, user string, err error) {
+	system, user, err = g.prompt.Render(g.prompt.Data(r, g.senderName))
+	if err != nil {
+		return "", "", err
+	}
+	if p := r.Previous; p != nil && p.Body != "" {
+		user += fmt.Sprintf("\n\nPrevious draft, to be rewritten:\nSUBJECT: %s\nBODY:\n%s", p.Subject, p.Body)
+	}
+	if hint := strings.TrimSpace(r.Hint); hint != "" {
+		user += "\n\nReviewer instruction for this email (takes priority over the rules above): " + hint
+	}
+	return system, user, nil
+}
+
+func (g *LLMGenerator) Generate(ctx context.Context, r Request) (*models.Email, error) {
+	system, user, err := g.render(r)
+	if err != nil {
+		return nil, err
+	}
+
+	email := &models.Email{
+		Contact:       r.Contact,
+		ContextSource: r.ContextSource,
+		Status:        models.StatusDraft,
+		GeneratedAt:   time.Now(),
+	}
+
+	if sc, ok := g.structured(); ok {
+		content, err := sc.completeJSON(ctx, system, user+jsonOutputInstructions, emailSchema)
+		switch {
+		case err == nil:
+			out, perr := parseStructuredEmail(content)
+			if perr == nil {
+				email.Subject = out.Subject
+				email.Body = out.Body
+				email.InferredRole = out.InferredRole
+				email.PersonalizationHook = out.PersonalizationHook
+				email.Confidence = out.Confidence
+				return email, nil
+			}
+			// Some models ignore the schema and answer in the text format anyway
+			subject, body, terr := parseEmailResponse(content)
+			if terr != nil {
+				return nil, fmt.Errorf("parsing LLM output: %w", perr)
+			}
+			email.Subject, email.Body = subject, body
+			return email, nil
+		case structuredUnsupported(err):
+			if !g.noSchema.Swap(true) {
+				log.Warn().Err(err).Msg("structured output rejected, using the text format from now on")
+			}
+		default:
+			return nil, err
+		}
+	}
+
+	content, err := g.client.complete(ctx, system, user+textOutputInstructions)
+	if err != nil {
+		return nil, err
+	}
+
+	subject, body, err := parseEmailResponse(content)
+	if err != nil {
+		return nil, fmt.Errorf("parsing LLM output: %w", err)
+	}
+
+	email.Subject, email.Body = subject, body
+	return email, nil
+}
+
+func parseEmailResponse(content string) (subject, body string, err error) {
+	lines := strings.Split(content, "\n")
+
+	subjectIdx := -1
+	bodyIdx := -1
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		upper := strings.ToUpper(trimmed)
+		if strings.HasPrefix(upper, "SUBJECT:") {
+			subjectIdx = i
+			subject = strings.TrimSpace(trimmed[len("SUBJECT:"):])
+		}
+		if strings.HasPrefix(upper, "BODY:") {
+			bodyIdx = i
+			// Some LLMs put body content on the same line as BODY:
+			inline := strings.TrimSpace(trimmed[len("BODY:"):])
+			if inline != "" {
+				bodyIdx = -2 // signal: body starts on this line
+				body = inline + "\n"
+			}
+		}
+	}
+
+	if subjectIdx == -1 {
+		return "", "", fmt.Errorf("could not find SUBJECT: in response:\n%s", content)
+	}
+
+	switch {
+	case bodyIdx == -2:
+		// body was already captured inline, but also grab remaining lines
+		foundBody := false
+		for _, line := range lines {
+			upper := strings.ToUpper(strings.TrimSpace(line))
+			if strings.HasPrefix(upper, "BODY:") {
+				foundBody = true
+				continue
+			}
+			if foundBody {
+				body += line + "\n"
+			}
+		}
+		body = strings.TrimSpace(body)
+	case bodyIdx >= 0:
+		bodyLines := lines[bodyIdx+1:]
+		body = strings.TrimSpace(strings.Join(bodyLines, "\n"))
+	default:
+		// No BODY: marker — treat everything after SUBJECT line as body
+		bodyLines := lines[subjectIdx+1:]
+		body = strings.TrimSpace(strings.Join(bodyLines, "\n"))
+	}
+
+	if body == "" {
+		return "", "", fmt.Errorf("empty body in response:\n%s", content)
+	}
+
+	return subject, body, nil
+}
@@ -0,0 +1,85 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/dantezy/cold-send0r-bot/internal/config"
+	"github.com/dantezy/cold-send0r-bot/internal/models"
+)
+
+// FollowUpData is what the follow-up template is executed against.
+type FollowUpData struct {
+	Contact      models.Contact
+	SenderName   string
+	Original     models.Email
+	Previous     []models.FollowUp
+	Step         int
+	BusinessDays int
+	Vars         map[string]string
+}
+
+// FollowUpWriter drafts short follow-ups that reply to an earlier email.
+type FollowUpWriter struct {
+	gen  *LLMGenerator
+	tmpl *template.Template
+}
+
+// NewFollowUpWriter uses gen's provider with the follow-up template from cfg,
+// or the built-in one.
+func NewFollowUpWriter(gen *LLMGenerator, cfg config.FollowupConfig) (*FollowUpWriter, error) {
+	tmpl, err := loadTemplate("followup", cfg.Template)
+	if err != nil {
+		return nil, err
+	}
+	return &FollowUpWriter{gen: gen, tmpl: tmpl}, nil
+}
+
+// Write drafts follow-up step for email, threaded as a reply to it.
+func (w *FollowUpWriter) Write(ctx context.Context, email models.Email, step, businessDays int) (*models.FollowUp, error) {
+	data := FollowUpData{
+		Contact:      email.Contact,
+		SenderName:   w.gen.senderName,
+		Original:     email,
+		Step:         step,
+		BusinessDays: businessDays,
+		Vars:         w.gen.prompt.vars,
+	}
+	for _, fu := range email.FollowUps {
+		if fu.Step < step && fu.SentAt != nil {
+			data.Previous = append(data.Previous, fu)
+		}
+	}
+
+	// The campaign's system prompt keeps the sender's voice consistent
+	system, err := execute(w.gen.prompt.system, w.gen.prompt.Data(Request{Contact: email.Contact}, w.gen.senderName))
+	if err != nil {
+		return nil, err
+	}
+	user, err := execute(w.tmpl, data)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := w.gen.client.complete(ctx, system, user)
+	if err != nil {
+		return nil, err
+	}
+
+	body := strings.TrimSpace(content)
+	if upper := strings.ToUpper(body); strings.HasPrefix(upper, "BODY:") {
+		body = strings.TrimSpace(body[len("BODY:"):])
+	}
+	if body == "" {
+		return nil, fmt.Errorf("empty follow-up body in response")
+	}
+
+	subject := email.Subject
+	if !strings.HasPrefix(strings.ToLower(subject), "re:") {
+		subject = "Re: " + subject
+	}
+
+	return &models.FollowUp{Step: step, Subject: subject, Body: body}, nil
+}
@@ -1,14 +1,47 @@
 package generator
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/dantezy/cold-send0r-bot/internal/config"
 	"github.com/dantezy/cold-send0r-bot/internal/models"
 )
 
 type Generator interface {
-	Generate(contact models.Contact, scrapeResult *models.ScrapeResult, resumeText string, links map[string]string) (*models.Email, error)
+	Generate(ctx context.Context, req Request) (*models.Email, error)
+}
+
+// Request carries everything needed to write one contact's email.
+type Request struct {
+	Contact    models.Contact
+	Scrape     *models.ScrapeResult
+	ResumeText string
+	Links      map[string]string
+	// Previous is the draft being regenerated and Hint a one-off
+	// instruction from the reviewer, e.g. "shorter, and mention their Rust
+	// SDK". Both are appended to the user prompt.
+	Previous *models.Email
+	Hint     string
+	// ContextSource records where Scrape came from when it is a fallback
+	// for a failed scrape, one of the models.Context* constants.
+	ContextSource string
 }
 
-func NewGenerator(cfg config.LLMConfig, senderName string) Generator {
-	return NewOpenRouterGenerator(cfg, senderName)
+// NewGenerator returns an LLMGenerator backed by cfg.Provider.
+func NewGenerator(cfg config.LLMConfig, prompt *Prompt, senderName string) (*LLMGenerator, error) {
+	var client chatClient
+	switch cfg.Provider {
+	case "", "openrouter":
+		client = newOpenAIClient(cfg, openRouterBaseURL)
+	case "openai":
+		client = newOpenAIClient(cfg, openAIBaseURL)
+	case "anthropic":
+		client = newAnthropicClient(cfg)
+	case "ollama":
+		client = newOllamaClient(cfg)
+	default:
+		return nil, fmt.Errorf("unknown llm provider %q (want openrouter, openai, anthropic or ollama)", cfg.Provider)
+	}
+	return &LLMGenerator{client: client, cfg: cfg, prompt: prompt, senderName: senderName}, nil
 }
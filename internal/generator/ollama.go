@@ -0,0 +1,122 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dantezy/cold-send0r-bot/internal/config"
+)
+
+const ollamaBaseURL = "http://localhost:11434"
+
+// ollamaClient uses Ollama's native /api/chat endpoint.
+type ollamaClient struct {
+	cfg     config.LLMConfig
+	baseURL string
+	client  *http.Client
+}
+
+func newOllamaClient(cfg config.LLMConfig) *ollamaClient {
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = ollamaBaseURL
+	}
+	return &ollamaClient{
+		cfg:     cfg,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		// Local models on modest hardware can take minutes per reply
+		client: &http.Client{Timeout: 5 * time.Minute},
+	}
+}
+
+type ollamaRequest struct {
+	Model    string        `json:"model"`
+	Messages []message     `json:"messages"`
+	Stream   bool          `json:"stream"`
+	Format   any           `json:"format,omitempty"`
+	Options  ollamaOptions `json:"options"`
+}
+
+type ollamaOptions struct {
+	Temperature float64 `json:"temperature"`
+	NumPredict  int     `json:"num_predict,omitempty"`
+}
+
+type ollamaResponse struct {
+	Message struct {
+		Content string `json:"content"`
+	} `json:"message"`
+	Error string `json:"error"`
+}
+
+func (g *ollamaClient) complete(ctx context.Context, system, user string) (string, error) {
+	return g.chat(ctx, system, user, nil)
+}
+
+func (g *ollamaClient) completeJSON(ctx context.Context, system, user string, schema map[string]any) (string, error) {
+	return g.chat(ctx, system, user, schema)
+}
+
+func (g *ollamaClient) chat(ctx context.Context, system, user string, format any) (string, error) {
+	var messages []message
+	if system != "" {
+		messages = append(messages, message{Role: "system", Content: system})
+	}
+	messages = append(messages, message{Role: "user", Content: user})
+
+	bodyBytes, err := json.Marshal(ollamaRequest{
+		Model:    g.cfg.Model,
+		Messages: messages,
+		Format:   format,
+		Options: ollamaOptions{
+			Temperature: g.cfg.Temperature,
+			NumPredict:  g.cfg.MaxTokens,
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("marshaling request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.baseURL+"/api/chat", bytes.NewReader(bodyBytes))
+	if err != nil {
+		return "", fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("calling ollama: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("reading response: %w", err)
+	}
+
+	var oResp ollamaResponse
+	jsonErr := json.Unmarshal(respBody, &oResp)
+
+	if resp.StatusCode != http.StatusOK {
+		msg := string(respBody)
+		if jsonErr == nil && oResp.Error != "" {
+			msg = oResp.Error
+		}
+		return "", newAPIError("ollama", resp, msg)
+	}
+	if jsonErr != nil {
+		return "", fmt.Errorf("parsing response: %w", jsonErr)
+	}
+
+	if oResp.Message.Content == "" {
+		return "", fmt.Errorf("empty message in response")
+	}
+
+	return oResp.Message.Content, nil
+}
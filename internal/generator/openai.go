@@ -0,0 +1,154 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dantezy/cold-send0r-bot/internal/config"
+)
+
+const (
+	openRouterBaseURL = "https://openrouter.ai/api/v1"
+	openAIBaseURL     = "https://api.openai.com/v1"
+)
+
+// openAIClient talks to any OpenAI-compatible chat completions API:
+// OpenAI itself, OpenRouter, or a local llama.cpp / Ollama / vLLM server.
+type openAIClient struct {
+	cfg     config.LLMConfig
+	name    string
+	baseURL string
+	client  *http.Client
+}
+
+func newOpenAIClient(cfg config.LLMConfig, defaultBaseURL string) *openAIClient {
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	name := cfg.Provider
+	if name == "" {
+		name = "openrouter"
+	}
+	return &openAIClient{
+		cfg:     cfg,
+		name:    name,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+type openAIRequest struct {
+	Model          string                `json:"model"`
+	Messages       []message             `json:"messages"`
+	Temperature    float64               `json:"temperature"`
+	MaxTokens      int                   `json:"max_tokens"`
+	ResponseFormat *openAIResponseFormat `json:"response_format,omitempty"`
+}
+
+type openAIResponseFormat struct {
+	Type       string           `json:"type"`
+	JSONSchema openAIJSONSchema `json:"json_schema"`
+}
+
+type openAIJSONSchema struct {
+	Name   string         `json:"name"`
+	Strict bool           `json:"strict"`
+	Schema map[string]any `json:"schema"`
+}
+
+type message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type openAIResponse struct {
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (g *openAIClient) complete(ctx context.Context, system, user string) (string, error) {
+	return g.chat(ctx, system, user, nil)
+}
+
+func (g *openAIClient) completeJSON(ctx context.Context, system, user string, schema map[string]any) (string, error) {
+	return g.chat(ctx, system, user, &openAIResponseFormat{
+		Type:       "json_schema",
+		JSONSchema: openAIJSONSchema{Name: "cold_email", Strict: true, Schema: schema},
+	})
+}
+
+func (g *openAIClient) chat(ctx context.Context, system, user string, format *openAIResponseFormat) (string, error) {
+	var messages []message
+	if system != "" {
+		messages = append(messages, message{Role: "system", Content: system})
+	}
+	messages = append(messages, message{Role: "user", Content: user})
+
+	bodyBytes, err := json.Marshal(openAIRequest{
+		Model:          g.cfg.Model,
+		Messages:       messages,
+		Temperature:    g.cfg.Temperature,
+		MaxTokens:      g.cfg.MaxTokens,
+		ResponseFormat: format,
+	})
+	if err != nil {
+		return "", fmt.Errorf("marshaling request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.baseURL+"/chat/completions", bytes.NewReader(bodyBytes))
+	if err != nil {
+		return "", fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if g.cfg.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+g.cfg.APIKey)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("calling %s: %w", g.name, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("reading response: %w", err)
+	}
+
+	var oaResp openAIResponse
+	jsonErr := json.Unmarshal(respBody, &oaResp)
+
+	if resp.StatusCode != http.StatusOK {
+		msg := string(respBody)
+		if jsonErr == nil && oaResp.Error != nil {
+			msg = oaResp.Error.Message
+		}
+		return "", newAPIError(g.name, resp, msg)
+	}
+	if jsonErr != nil {
+		return "", fmt.Errorf("parsing response: %w", jsonErr)
+	}
+
+	if oaResp.Error != nil {
+		return "", fmt.Errorf("%s error: %s", g.name, oaResp.Error.Message)
+	}
+
+	if len(oaResp.Choices) == 0 {
+		return "", fmt.Errorf("no choices in response")
+	}
+
+	return oaResp.Choices[0].Message.Content, nil
+}
@@ -0,0 +1,176 @@
+package generator
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/dantezy/cold-send0r-bot/internal/budget"
+	"github.com/dantezy/cold-send0r-bot/internal/config"
+	"github.com/dantezy/cold-send0r-bot/internal/models"
+	"github.com/dantezy/cold-send0r-bot/internal/ratelimit"
+)
+
+// promptOverheadChars approximates the fixed instructions wrapped around the
+// scraped content and resume in every prompt.
+const promptOverheadChars = 2000
+
+// Pool runs a Generator over many requests concurrently, throttled by
+// requests-per-minute and tokens-per-minute buckets, retrying rate limits
+// and server errors with exponential backoff.
+type Pool struct {
+	gen         Generator
+	maxTokens   int
+	concurrency int
+	requests    *ratelimit.Bucket
+	tokens      *ratelimit.Bucket
+	retry       retryPolicy
+}
+
+func NewPool(gen Generator, cfg config.LLMConfig) *Pool {
+	concurrency := cfg.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	rpm := cfg.RequestsPerMinute
+	if rpm <= 0 && cfg.RateLimitMs > 0 {
+		rpm = int(time.Minute / (time.Duration(cfg.RateLimitMs) * time.Millisecond))
+	}
+
+	base := time.Duration(cfg.RetryBaseMs) * time.Millisecond
+	if base <= 0 {
+		base = time.Second
+	}
+
+	return &Pool{
+		gen:         gen,
+		maxTokens:   cfg.MaxTokens,
+		concurrency: concurrency,
+		requests:    ratelimit.NewBucket(rpm),
+		tokens:      ratelimit.NewBucket(cfg.TokensPerMinute),
+		retry:       retryPolicy{maxRetries: cfg.MaxRetries, base: base, max: time.Minute},
+	}
+}
+
+// Run generates an email for every request and calls fn as each completes.
+// Calls to fn are serialized. On failure fn receives the error together with
+// a FailedEmail record. When ctx is cancelled, queued requests are dropped
+// and Run returns ctx.Err().
+func (p *Pool) Run(ctx context.Context, reqs []Request, fn func(req Request, email *models.Email, err error)) error {
+	type outcome struct {
+		req   Request
+		email *models.Email
+		err   error
+	}
+
+	jobs := make(chan Request)
+	results := make(chan outcome)
+
+	var wg sync.WaitGroup
+	for i := 0; i < p.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for req := range jobs {
+				email, err := p.Generate(ctx, req)
+				if ctx.Err() != nil {
+					continue
+				}
+				if err != nil {
+					email = FailedEmail(req.Contact, err)
+				}
+				results <- outcome{req, email, err}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for _, req := range reqs {
+			select {
+			case jobs <- req:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	for o := range results {
+		fn(o.req, o.email, o.err)
+	}
+	return ctx.Err()
+}
+
+// Generate runs a single request through the rate limiters and retry policy.
+func (p *Pool) Generate(ctx context.Context, req Request) (*models.Email, error) {
+	for attempt := 0; ; attempt++ {
+		if err := p.requests.Wait(ctx, 1); err != nil {
+			return nil, err
+		}
+		if err := p.tokens.Wait(ctx, p.estimateTokens(req)); err != nil {
+			return nil, err
+		}
+
+		email, err := p.gen.Generate(ctx, req)
+		if err == nil || ctx.Err() != nil || attempt >= p.retry.maxRetries || !retryable(err) {
+			return email, err
+		}
+
+		d := p.retry.delay(attempt, err)
+		log.Warn().Str("contact", req.Contact.Email).Int("attempt", attempt+1).Dur("backoff", d).Err(err).Msg("generation failed, retrying")
+		if err := sleep(ctx, d); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// messager is implemented by generators that can render their prompt
+// without sending it.
+type messager interface {
+	Messages(r Request) (system, user string, err error)
+}
+
+// estimateTokens guesses prompt plus completion size at ~4 characters per
+// token, which is close enough for budgeting against tokens_per_minute.
+// The rendered prompt is measured when the generator can produce it.
+func (p *Pool) estimateTokens(req Request) int {
+	if m, ok := p.gen.(messager); ok {
+		if system, user, err := m.Messages(req); err == nil {
+			return budget.Tokens(system) + budget.Tokens(user) + p.maxTokens
+		}
+	}
+
+	chars := promptOverheadChars + len(req.ResumeText)
+	for _, f := range req.Contact.Facts() {
+		chars += len(f) + 3
+	}
+	if req.Scrape != nil {
+		chars += len(req.Scrape.Markdown)
+		for _, f := range req.Scrape.Signals.Facts() {
+			chars += len(f) + 3
+		}
+	}
+	if req.Previous != nil {
+		chars += len(req.Previous.Subject) + len(req.Previous.Body)
+	}
+	return chars/4 + p.maxTokens
+}
+
+// FailedEmail is the output record for a contact whose generation failed,
+// kept so it can be retried later with generate --retry-failed.
+func FailedEmail(c models.Contact, err error) *models.Email {
+	return &models.Email{
+		Contact:     c,
+		Status:      models.StatusGenerationFailed,
+		Error:       err.Error(),
+		GeneratedAt: time.Now(),
+	}
+}
@@ -1,89 +1,149 @@
 package generator
 
 import (
+	"bytes"
+	"embed"
 	"fmt"
+	"os"
+	"sort"
 	"strings"
+	"text/template"
 
+	"github.com/dantezy/cold-send0r-bot/internal/budget"
+	"github.com/dantezy/cold-send0r-bot/internal/config"
 	"github.com/dantezy/cold-send0r-bot/internal/models"
 )
 
-func BuildPrompt(contact models.Contact, scrapeMarkdown, resumeText, senderName string, links map[string]string) string {
-	firstName := strings.Split(contact.Name, " ")[0]
+//go:embed templates/*.tmpl
+var builtinTemplates embed.FS
 
-	companyContext := scrapeMarkdown
-	if companyContext == "" {
-		companyContext = fmt.Sprintf("(No website content available. Use the company name '%s' and role '%s' as context.)", contact.Company, contact.Role)
-	}
+// PromptData is what system and user templates are executed against.
+type PromptData struct {
+	Contact        models.Contact
+	FirstName      string
+	Greeting       string
+	SenderName     string
+	Scrape         *models.ScrapeResult
+	CompanyContent string
+	ContextSource  string
+	Facts          []string
+	ContactFacts   []string
+	Resume         string
+	Links          map[string]string
+	LinkLabels     []string
+	Vars           map[string]string
+}
 
-	// Decide greeting style: use first name if we have a person, team name if generic
-	greeting := fmt.Sprintf("Hi %s,", firstName)
-	if contact.Role == "" || strings.Contains(strings.ToLower(contact.Name), "team") {
-		greeting = fmt.Sprintf("Dear %s Hiring Team,", contact.Company)
-	}
+// Prompt is a parsed pair of system and user templates.
+type Prompt struct {
+	system        *template.Template
+	user          *template.Template
+	vars          map[string]string
+	contentTokens int
+}
 
-	// Build links section dynamically from config
-	var linksSection string
-	if len(links) > 0 {
-		var linkLines []string
-		for label, url := range links {
-			linkLines = append(linkLines, fmt.Sprintf("- %s: %s", label, url))
+var templateFuncs = template.FuncMap{
+	"join":  strings.Join,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"trim":  strings.TrimSpace,
+	"default": func(def, v string) string {
+		if strings.TrimSpace(v) == "" {
+			return def
 		}
-		linksSection = strings.Join(linkLines, "\n")
-	} else {
-		linksSection = "(No links provided)"
+		return v
+	},
+}
+
+// LoadPrompt parses the templates named in cfg, falling back to the
+// built-in ones for any path left empty.
+func LoadPrompt(cfg config.PromptConfig) (*Prompt, error) {
+	system, err := loadTemplate("system", cfg.SystemTemplate)
+	if err != nil {
+		return nil, err
 	}
+	user, err := loadTemplate("user", cfg.UserTemplate)
+	if err != nil {
+		return nil, err
+	}
+	return &Prompt{system: system, user: user, vars: cfg.Vars, contentTokens: cfg.ContentTokens}, nil
+}
 
-	// Build a list of link labels for the prompt instruction
-	var linkLabels []string
-	for label := range links {
-		linkLabels = append(linkLabels, label)
+func loadTemplate(name, path string) (*template.Template, error) {
+	var data []byte
+	var err error
+	if path == "" {
+		data, err = builtinTemplates.ReadFile("templates/" + name + ".tmpl")
+	} else {
+		data, err = os.ReadFile(path)
 	}
-	linkMention := strings.Join(linkLabels, "/")
-	if linkMention == "" {
-		linkMention = "relevant"
+	if err != nil {
+		return nil, fmt.Errorf("reading %s prompt template: %w", name, err)
 	}
 
-	return fmt.Sprintf(`Write a cold outreach email from %s to %s (%s) at %s.
+	tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=zero").Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s prompt template: %w", name, err)
+	}
+	return tmpl, nil
+}
 
-Company website content:
-%s
+// Data assembles the template data for one request. CompanyContent holds
+// the scraped sections most relevant to the contact's role and the resume
+// that fit in the content token budget.
+func (p *Prompt) Data(r Request, senderName string) PromptData {
+	contact := r.Contact
+	firstName := strings.Split(contact.Name, " ")[0]
 
-Sender's background:
-%s
+	// Decide greeting style: use first name if we have a person, team name if generic
+	greeting := fmt.Sprintf("Hi %s,", firstName)
+	if contact.Role == "" || strings.Contains(strings.ToLower(contact.Name), "team") {
+		greeting = fmt.Sprintf("Dear %s Hiring Team,", contact.Company)
+	}
 
-Sender's links:
-%s
+	var labels []string
+	for label := range r.Links {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
 
-Style rules (FOLLOW STRICTLY — match this exact tone and structure):
-- Professional but warm, NOT overly casual
-- Opening: "%s" (already provided, use as-is)
-- Body: 2-3 short paragraphs, max 5 sentences total
-- First paragraph: State interest in contributing to the company, reference something SPECIFIC from their website that caught your attention
-- Second paragraph: Briefly connect sender's relevant experience to what the company does
-- Third paragraph (short): Mention CV is attached, include the sender's %s links, and say you're available to discuss further
-- Sign off with exactly: "Regards,\n%s"
-- Do NOT use "hey" — keep it professional
-- Do NOT use "I hope this finds you well" or generic corporate openers
-- Do NOT use markdown formatting like ** or ## in the email body
-- Keep it concise and direct, like a real person writing a real email
+	data := PromptData{
+		Contact:       contact,
+		FirstName:     firstName,
+		Greeting:      greeting,
+		SenderName:    senderName,
+		Scrape:        r.Scrape,
+		ContextSource: r.ContextSource,
+		ContactFacts:  contact.Facts(),
+		Resume:        r.ResumeText,
+		Links:         r.Links,
+		LinkLabels:    labels,
+		Vars:          p.vars,
+	}
+	if r.Scrape != nil {
+		data.CompanyContent = budget.Pack(r.Scrape.Markdown, budget.NewQuery(contact.Role, r.ResumeText), p.contentTokens)
+		data.Facts = r.Scrape.Signals.Facts()
+	}
+	return data
+}
 
-Subject line rules:
-- Format: "<Role/Position> Application – %s"
-- Examples: "Senior Backend Developer Application – %s", "Full Stack Developer Application – %s"
-- Infer an appropriate role/position from the company website content and sender's background
-- Always end with " – %s" (en-dash, then sender full name)
+// Render executes both templates. The output-format instructions are not
+// part of the templates; the generator appends them so a custom template
+// cannot break response parsing.
+func (p *Prompt) Render(data PromptData) (system, user string, err error) {
+	if system, err = execute(p.system, data); err != nil {
+		return "", "", err
+	}
+	if user, err = execute(p.user, data); err != nil {
+		return "", "", err
+	}
+	return system, user, nil
+}
 
-Output exactly:
-SUBJECT: <subject line>
-BODY:
-<email body>`,
-		senderName, contact.Name, contact.Role, contact.Company,
-		companyContext,
-		resumeText,
-		linksSection,
-		greeting,
-		linkMention,
-		senderName,
-		senderName, senderName, senderName,
-	)
+func execute(t *template.Template, data any) (string, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("rendering %s prompt: %w", t.Name(), err)
+	}
+	return strings.TrimSpace(buf.String()), nil
 }
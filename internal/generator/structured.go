@@ -0,0 +1,101 @@
+package generator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// structuredClient is implemented by providers that can constrain a reply
+// to a JSON schema.
+type structuredClient interface {
+	completeJSON(ctx context.Context, system, user string, schema map[string]any) (string, error)
+}
+
+const jsonOutputInstructions = `
+
+Respond with a single JSON object with these fields:
+- "subject": the subject line
+- "body": the full email body as plain text
+- "inferred_role": the role/position you inferred for the subject line
+- "personalization_hook": the specific detail from the company content you referenced
+- "confidence": 0 to 1, how well the company content supported real personalization`
+
+var emailSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"subject":              map[string]any{"type": "string"},
+		"body":                 map[string]any{"type": "string"},
+		"inferred_role":        map[string]any{"type": "string"},
+		"personalization_hook": map[string]any{"type": "string"},
+		"confidence":           map[string]any{"type": "number"},
+	},
+	"required":             []string{"subject", "body", "inferred_role", "personalization_hook", "confidence"},
+	"additionalProperties": false,
+}
+
+type structuredEmail struct {
+	Subject             string  `json:"subject"`
+	Body                string  `json:"body"`
+	InferredRole        string  `json:"inferred_role"`
+	PersonalizationHook string  `json:"personalization_hook"`
+	Confidence          float64 `json:"confidence"`
+}
+
+// parseStructuredEmail decodes and validates a schema-constrained reply.
+// Markdown code fences around the object are tolerated.
+func parseStructuredEmail(content string) (*structuredEmail, error) {
+	content = strings.TrimSpace(content)
+	if strings.HasPrefix(content, "```") {
+		content = strings.TrimPrefix(content, "```json")
+		content = strings.TrimPrefix(content, "```")
+		content = strings.TrimSuffix(strings.TrimSpace(content), "```")
+	}
+
+	var out structuredEmail
+	if err := json.Unmarshal([]byte(content), &out); err != nil {
+		return nil, fmt.Errorf("decoding structured response: %w", err)
+	}
+
+	out.Subject = strings.TrimSpace(out.Subject)
+	out.Body = strings.TrimSpace(out.Body)
+	switch {
+	case out.Subject == "":
+		return nil, fmt.Errorf("structured response has empty subject")
+	case out.Body == "":
+		return nil, fmt.Errorf("structured response has empty body")
+	case out.Confidence < 0 || out.Confidence > 1:
+		return nil, fmt.Errorf("structured response confidence %v out of range", out.Confidence)
+	}
+	return &out, nil
+}
+
+// schemaRejectionHints are fragments of the error messages providers send
+// when they refuse response_format or the schema itself.
+var schemaRejectionHints = []string{"response_format", "json_schema", "structured output", "structured_output", "schema"}
+
+// structuredUnsupported reports whether err means the provider or model
+// rejected the structured output request itself, as opposed to failing.
+// A bad request for any other reason (context too long, bad model name)
+// would fail the text request too, so only the status code is not enough.
+func structuredUnsupported(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.StatusCode {
+	case http.StatusBadRequest, http.StatusNotFound, http.StatusUnprocessableEntity, http.StatusNotImplemented:
+	default:
+		return false
+	}
+	msg := strings.ToLower(apiErr.Message)
+	for _, hint := range schemaRejectionHints {
+		if strings.Contains(msg, hint) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,189 @@
+package inbox
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Client is a minimal IMAP4rev1 client covering what inbox sync needs:
+// login, select, UID SEARCH and UID FETCH of full messages. It works over
+// any net.Conn, so it can be pointed at an in-process server in tests.
+type Client struct {
+	conn net.Conn
+	r    *bufio.Reader
+	tag  int
+}
+
+// response is one server response line with any literals it carried.
+type response struct {
+	text     string
+	literals [][]byte
+}
+
+var literalRe = regexp.MustCompile(`\{(\d+)\}$`)
+
+// Dial connects to addr, over implicit TLS when useTLS is set.
+func Dial(addr string, useTLS bool, timeout time.Duration) (*Client, error) {
+	dialer := &net.Dialer{Timeout: timeout}
+	var conn net.Conn
+	var err error
+	if useTLS {
+		host, _, _ := net.SplitHostPort(addr)
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{ServerName: host})
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("connecting to %s: %w", addr, err)
+	}
+	return NewClient(conn)
+}
+
+// NewClient reads the server greeting from an established connection.
+func NewClient(conn net.Conn) (*Client, error) {
+	c := &Client{conn: conn, r: bufio.NewReader(conn)}
+	greeting, err := c.readResponse()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("reading greeting: %w", err)
+	}
+	if !strings.HasPrefix(greeting.text, "* OK") && !strings.HasPrefix(greeting.text, "* PREAUTH") {
+		conn.Close()
+		return nil, fmt.Errorf("unexpected greeting: %s", greeting.text)
+	}
+	return c, nil
+}
+
+func (c *Client) Login(username, password string) error {
+	_, err := c.command("LOGIN %s %s", quote(username), quote(password))
+	return err
+}
+
+func (c *Client) Select(mailbox string) error {
+	_, err := c.command("SELECT %s", quote(mailbox))
+	return err
+}
+
+// SearchSince returns the UIDs of messages received on or after since.
+func (c *Client) SearchSince(since time.Time) ([]uint32, error) {
+	resps, err := c.command("UID SEARCH SINCE %s", since.Format("02-Jan-2006"))
+	if err != nil {
+		return nil, err
+	}
+
+	var uids []uint32
+	for _, r := range resps {
+		if !strings.HasPrefix(r.text, "* SEARCH") {
+			continue
+		}
+		for _, f := range strings.Fields(strings.TrimPrefix(r.text, "* SEARCH")) {
+			n, err := strconv.ParseUint(f, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("parsing search result %q: %w", f, err)
+			}
+			uids = append(uids, uint32(n))
+		}
+	}
+	return uids, nil
+}
+
+// Fetch returns the raw RFC 5322 bytes of each message, without marking
+// them as read.
+func (c *Client) Fetch(uids []uint32) ([][]byte, error) {
+	if len(uids) == 0 {
+		return nil, nil
+	}
+	set := make([]string, len(uids))
+	for i, u := range uids {
+		set[i] = strconv.FormatUint(uint64(u), 10)
+	}
+
+	resps, err := c.command("UID FETCH %s (BODY.PEEK[])", strings.Join(set, ","))
+	if err != nil {
+		return nil, err
+	}
+
+	var msgs [][]byte
+	for _, r := range resps {
+		if strings.HasPrefix(r.text, "* ") && strings.Contains(r.text, " FETCH ") && len(r.literals) > 0 {
+			msgs = append(msgs, r.literals[0])
+		}
+	}
+	return msgs, nil
+}
+
+func (c *Client) Logout() error {
+	_, err := c.command("LOGOUT")
+	c.conn.Close()
+	return err
+}
+
+// command sends a tagged command and collects untagged responses until the
+// tagged completion, which must be OK.
+func (c *Client) command(format string, args ...any) ([]response, error) {
+	c.tag++
+	tag := fmt.Sprintf("a%03d", c.tag)
+	line := tag + " " + fmt.Sprintf(format, args...) + "\r\n"
+	if _, err := io.WriteString(c.conn, line); err != nil {
+		return nil, fmt.Errorf("writing command: %w", err)
+	}
+
+	var untagged []response
+	for {
+		r, err := c.readResponse()
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(r.text, tag+" ") {
+			untagged = append(untagged, r)
+			continue
+		}
+
+		status := strings.TrimPrefix(r.text, tag+" ")
+		if !strings.HasPrefix(status, "OK") {
+			cmd := strings.Fields(format)[0]
+			return nil, fmt.Errorf("imap %s failed: %s", cmd, status)
+		}
+		return untagged, nil
+	}
+}
+
+// readResponse reads one logical response line, following {n} literals.
+func (c *Client) readResponse() (response, error) {
+	var r response
+	var text strings.Builder
+	for {
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			return r, fmt.Errorf("reading response: %w", err)
+		}
+		line = strings.TrimRight(line, "\r\n")
+		text.WriteString(line)
+
+		m := literalRe.FindStringSubmatch(line)
+		if m == nil {
+			r.text = text.String()
+			return r, nil
+		}
+
+		n, _ := strconv.Atoi(m[1])
+		lit := make([]byte, n)
+		if _, err := io.ReadFull(c.r, lit); err != nil {
+			return r, fmt.Errorf("reading literal: %w", err)
+		}
+		r.literals = append(r.literals, lit)
+	}
+}
+
+func quote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
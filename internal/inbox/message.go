@@ -0,0 +1,187 @@
+package inbox
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+)
+
+type Kind string
+
+const (
+	KindReply     Kind = "reply"
+	KindAutoReply Kind = "auto_reply"
+	KindBounce    Kind = "bounce"
+)
+
+// Message is an incoming message reduced to what matching needs.
+type Message struct {
+	Kind      Kind
+	From      string
+	Subject   string
+	MessageID string
+	Date      time.Time
+	// References holds every Message-ID this message points back to:
+	// In-Reply-To, References and, for bounces, the returned original.
+	References []string
+	// Recipients lists the failed addresses reported by a bounce.
+	Recipients []string
+}
+
+var (
+	messageIDRe  = regexp.MustCompile(`<[^<>\s]+@[^<>\s]+>`)
+	headerIDRe   = regexp.MustCompile(`(?im)^Message-ID:\s*(<[^<>\s]+>)`)
+	autoSubjects = []string{"automatic reply", "auto reply", "autoreply", "auto-reply", "out of office", "out of the office", "away from the office", "on vacation", "auto:"}
+)
+
+// Parse reads a raw RFC 5322 message and classifies it as a reply, an
+// auto-reply or a bounce. Delivery status notifications (RFC 3464) are
+// parsed for their failed recipients and the original Message-ID. Only a
+// notice that reports a failed recipient is a bounce; others, such as
+// "Action: delayed" warnings, are treated as auto-replies.
+func Parse(raw []byte) (*Message, error) {
+	m, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+
+	dec := new(mime.WordDecoder)
+	subject, err := dec.DecodeHeader(m.Header.Get("Subject"))
+	if err != nil {
+		subject = m.Header.Get("Subject")
+	}
+
+	msg := &Message{
+		Kind:      KindReply,
+		Subject:   subject,
+		MessageID: strings.TrimSpace(m.Header.Get("Message-ID")),
+	}
+	if from, err := m.Header.AddressList("From"); err == nil && len(from) > 0 {
+		msg.From = strings.ToLower(from[0].Address)
+	}
+	if d, err := m.Header.Date(); err == nil {
+		msg.Date = d
+	}
+	msg.References = append(msg.References, messageIDRe.FindAllString(m.Header.Get("In-Reply-To"), -1)...)
+	msg.References = append(msg.References, messageIDRe.FindAllString(m.Header.Get("References"), -1)...)
+
+	mediaType, params, _ := mime.ParseMediaType(m.Header.Get("Content-Type"))
+	switch {
+	case mediaType == "multipart/report" && strings.EqualFold(params["report-type"], "delivery-status"):
+		parseDSN(m.Body, params["boundary"], msg)
+		msg.Kind = dsnKind(msg)
+	case isMailerDaemon(msg.From):
+		// Non-standard bounce: the original headers are usually quoted in the body
+		body, _ := io.ReadAll(m.Body)
+		for _, match := range headerIDRe.FindAllSubmatch(body, -1) {
+			msg.References = append(msg.References, string(match[1]))
+		}
+		msg.Recipients = failedRecipients(bytes.NewReader(body))
+		msg.Kind = dsnKind(msg)
+	case isAutoReply(m.Header, subject):
+		msg.Kind = KindAutoReply
+	}
+
+	return msg, nil
+}
+
+// dsnKind classifies a delivery notice: a bounce when it names a failed
+// recipient, and otherwise an auto-reply, which changes nothing.
+func dsnKind(msg *Message) Kind {
+	if len(msg.Recipients) > 0 {
+		return KindBounce
+	}
+	return KindAutoReply
+}
+
+func parseDSN(body io.Reader, boundary string, msg *Message) {
+	if boundary == "" {
+		return
+	}
+	mr := multipart.NewReader(body, boundary)
+	for {
+		part, err := mr.NextPart()
+		if err != nil {
+			return
+		}
+		mediaType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		switch mediaType {
+		case "message/delivery-status":
+			msg.Recipients = append(msg.Recipients, failedRecipients(part)...)
+		case "message/rfc822", "text/rfc822-headers":
+			if orig, err := mail.ReadMessage(part); err == nil {
+				if id := strings.TrimSpace(orig.Header.Get("Message-ID")); id != "" {
+					msg.References = append(msg.References, id)
+				}
+			}
+		}
+	}
+}
+
+// failedRecipients reads the per-recipient blocks of a delivery-status part
+// and returns the addresses whose Action is failed.
+func failedRecipients(r io.Reader) []string {
+	var out []string
+	var recipient, action string
+	flush := func() {
+		if recipient != "" && (action == "" || action == "failed") {
+			out = append(out, recipient)
+		}
+		recipient, action = "", ""
+	}
+
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			flush()
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.ToLower(name) {
+		case "final-recipient", "original-recipient":
+			// "rfc822; user@example.com"
+			if _, addr, ok := strings.Cut(value, ";"); ok && recipient == "" {
+				recipient = strings.ToLower(strings.Trim(strings.TrimSpace(addr), "<>"))
+			}
+		case "action":
+			action = strings.ToLower(value)
+		}
+	}
+	flush()
+	return out
+}
+
+func isMailerDaemon(from string) bool {
+	local, _, _ := strings.Cut(from, "@")
+	return local == "mailer-daemon" || local == "postmaster"
+}
+
+func isAutoReply(h mail.Header, subject string) bool {
+	if v := strings.ToLower(h.Get("Auto-Submitted")); v != "" && v != "no" {
+		return true
+	}
+	if h.Get("X-Autoreply") != "" || h.Get("X-Autorespond") != "" {
+		return true
+	}
+	if strings.EqualFold(h.Get("Precedence"), "auto_reply") {
+		return true
+	}
+	s := strings.ToLower(subject)
+	for _, prefix := range autoSubjects {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,166 @@
+package inbox
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/dantezy/cold-send0r-bot/internal/config"
+	"github.com/dantezy/cold-send0r-bot/internal/models"
+)
+
+// Mailbox yields the raw messages received since a point in time.
+type Mailbox interface {
+	Messages(since time.Time) ([][]byte, error)
+}
+
+// IMAPMailbox reads a mailbox over IMAP with the configured account.
+type IMAPMailbox struct {
+	cfg config.IMAPConfig
+}
+
+func NewIMAPMailbox(cfg config.IMAPConfig) *IMAPMailbox {
+	return &IMAPMailbox{cfg: cfg}
+}
+
+func (m *IMAPMailbox) Messages(since time.Time) ([][]byte, error) {
+	if m.cfg.Username == "" || m.cfg.Password == "" {
+		return nil, fmt.Errorf("IMAP credentials not configured (set %s and %s env vars)", m.cfg.UsernameEnv, m.cfg.PasswordEnv)
+	}
+
+	addr := net.JoinHostPort(m.cfg.Host, strconv.Itoa(m.cfg.Port))
+	c, err := Dial(addr, !m.cfg.Insecure, 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Logout()
+
+	if err := c.Login(m.cfg.Username, m.cfg.Password); err != nil {
+		return nil, err
+	}
+	mailbox := m.cfg.Mailbox
+	if mailbox == "" {
+		mailbox = "INBOX"
+	}
+	if err := c.Select(mailbox); err != nil {
+		return nil, err
+	}
+
+	uids, err := c.SearchSince(since)
+	if err != nil {
+		return nil, err
+	}
+	log.Debug().Int("messages", len(uids)).Str("mailbox", mailbox).Msg("fetching messages")
+	return c.Fetch(uids)
+}
+
+// Result summarizes what a sync changed.
+type Result struct {
+	Replies     int
+	AutoReplies int
+	Bounces     int
+	Unmatched   int
+	// Bounced lists addresses that hard-bounced and should be suppressed.
+	Bounced []string
+}
+
+// Apply matches incoming messages to sent emails and records them. Matching
+// prefers Message-ID threading (In-Reply-To/References, or the original
+// inside a bounce) and falls back to the sender or bounced address. Replies
+// and bounces update the email status, which ends its follow-up sequence;
+// auto-replies are recorded but leave the sequence running. Messages already
+// recorded are skipped, so syncing the same window twice is harmless.
+func Apply(emails []models.Email, msgs []*Message) Result {
+	byID := make(map[string]int)
+	byAddr := make(map[string]int)
+	for i, e := range emails {
+		if e.MessageID == "" {
+			continue
+		}
+		byID[e.MessageID] = i
+		for _, fu := range e.FollowUps {
+			if fu.MessageID != "" {
+				byID[fu.MessageID] = i
+			}
+		}
+		byAddr[strings.ToLower(e.Contact.Email)] = i
+	}
+
+	var res Result
+	for _, msg := range msgs {
+		idx, ok := match(msg, byID, byAddr)
+		if !ok {
+			res.Unmatched++
+			continue
+		}
+
+		email := &emails[idx]
+		if seen(email, msg.MessageID) {
+			continue
+		}
+
+		email.Inbox = append(email.Inbox, models.InboxEvent{
+			Kind:       string(msg.Kind),
+			From:       msg.From,
+			Subject:    msg.Subject,
+			MessageID:  msg.MessageID,
+			ReceivedAt: msg.Date,
+		})
+
+		switch msg.Kind {
+		case KindReply:
+			res.Replies++
+			email.Status = models.StatusReplied
+		case KindBounce:
+			res.Bounces++
+			// A notice about some other address, say one the contact
+			// forwards to, says nothing about theirs
+			if !slices.Contains(msg.Recipients, strings.ToLower(email.Contact.Email)) {
+				break
+			}
+			if email.Status != models.StatusReplied {
+				email.Status = models.StatusBounced
+			}
+			res.Bounced = append(res.Bounced, email.Contact.Email)
+		case KindAutoReply:
+			res.AutoReplies++
+		}
+		log.Info().Str("contact", email.Contact.Email).Str("kind", string(msg.Kind)).Str("subject", msg.Subject).Msg("matched inbox message")
+	}
+	return res
+}
+
+func match(msg *Message, byID, byAddr map[string]int) (int, bool) {
+	for _, ref := range msg.References {
+		if i, ok := byID[ref]; ok {
+			return i, true
+		}
+	}
+	if msg.Kind == KindBounce {
+		for _, r := range msg.Recipients {
+			if i, ok := byAddr[r]; ok {
+				return i, true
+			}
+		}
+		return 0, false
+	}
+	i, ok := byAddr[msg.From]
+	return i, ok
+}
+
+func seen(email *models.Email, messageID string) bool {
+	if messageID == "" {
+		return false
+	}
+	for _, ev := range email.Inbox {
+		if ev.MessageID == messageID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,330 @@
+package inbox
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dantezy/cold-send0r-bot/internal/ledger"
+	"github.com/dantezy/cold-send0r-bot/internal/models"
+)
+
+const (
+	replyByID = `From: Bob <bob@acme.com>
+To: me@example.com
+Subject: Re: Backend role
+Message-ID: <r1@acme.com>
+In-Reply-To: <sent-ann@example.com>
+Date: Mon, 2 Mar 2026 10:00:00 +0000
+
+Forwarded to me by Ann, happy to chat.
+`
+
+	replyToFollowUp = `From: Carl <carl@corp.io>
+Subject: Re: Following up
+Message-ID: <r2@corp.io>
+References: <unrelated@corp.io> <fu-carl@example.com>
+Date: Mon, 2 Mar 2026 11:00:00 +0000
+
+Sorry for the delay.
+`
+
+	replyBySender = `From: Dana <DANA@startup.dev>
+Subject: Hello
+Message-ID: <r3@startup.dev>
+Date: Mon, 2 Mar 2026 12:00:00 +0000
+
+Got your note.
+`
+
+	autoReply = `From: ann@acme.com
+Subject: Thanks for your email
+Auto-Submitted: auto-replied
+Message-ID: <a1@acme.com>
+In-Reply-To: <sent-ann@example.com>
+
+I will get back to you.
+`
+
+	outOfOffice = `From: carl@corp.io
+Subject: Out of Office: back on Monday
+Message-ID: <a2@corp.io>
+
+Away until Monday.
+`
+
+	failedDSN = `From: Mail Delivery System <MAILER-DAEMON@mx.gone.com>
+Subject: Undelivered Mail Returned to Sender
+Message-ID: <b1@mx.gone.com>
+Content-Type: multipart/report; report-type=delivery-status; boundary="XX"
+
+--XX
+Content-Type: text/plain
+
+Your message could not be delivered.
+--XX
+Content-Type: message/delivery-status
+
+Reporting-MTA: dns; mx.gone.com
+
+Final-Recipient: rfc822; eve@gone.com
+Action: failed
+Status: 5.1.1
+--XX
+Content-Type: text/rfc822-headers
+
+From: me@example.com
+To: eve@gone.com
+Message-ID: <sent-eve@example.com>
+--XX--
+`
+
+	delayedDSN = `From: MAILER-DAEMON@mx.slow.com
+Subject: Delivery delayed
+Message-ID: <b2@mx.slow.com>
+Content-Type: multipart/report; report-type=delivery-status; boundary="YY"
+
+--YY
+Content-Type: message/delivery-status
+
+Final-Recipient: rfc822; fay@slow.com
+Action: delayed
+Status: 4.4.7
+--YY
+Content-Type: text/rfc822-headers
+
+Message-ID: <sent-fay@example.com>
+--YY--
+`
+
+	stranger = `From: someone@else.org
+Subject: Unrelated
+Message-ID: <x1@else.org>
+
+Hi.
+`
+)
+
+// serve runs a fake IMAP server over a pipe holding msgs and returns a
+// client connected to it.
+func serve(t *testing.T, msgs []string) *Client {
+	t.Helper()
+	client, server := net.Pipe()
+	t.Cleanup(func() { server.Close() })
+
+	go func() {
+		r := bufio.NewReader(server)
+		fmt.Fprint(server, "* OK fake IMAP ready\r\n")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			tag, cmd, _ := strings.Cut(strings.TrimRight(line, "\r\n"), " ")
+			switch {
+			case strings.HasPrefix(cmd, "UID SEARCH"):
+				var uids []string
+				for i := range msgs {
+					uids = append(uids, strconv.Itoa(i+1))
+				}
+				fmt.Fprintf(server, "* SEARCH %s\r\n", strings.Join(uids, " "))
+			case strings.HasPrefix(cmd, "UID FETCH"):
+				set := strings.Fields(cmd)[2]
+				for _, f := range strings.Split(set, ",") {
+					uid, _ := strconv.Atoi(f)
+					raw := msgs[uid-1]
+					fmt.Fprintf(server, "* %d FETCH (UID %d BODY[] {%d}\r\n%s)\r\n", uid, uid, len(raw), raw)
+				}
+			case cmd == "LOGOUT":
+				fmt.Fprintf(server, "* BYE\r\n%s OK LOGOUT completed\r\n", tag)
+				return
+			}
+			fmt.Fprintf(server, "%s OK done\r\n", tag)
+		}
+	}()
+
+	c, err := NewClient(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Logout() })
+	return c
+}
+
+// fetch reads every message from a fake server and parses it.
+func fetch(t *testing.T, raws ...string) []*Message {
+	t.Helper()
+	c := serve(t, raws)
+	if err := c.Login("me@example.com", `pa"ss`); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Select("INBOX"); err != nil {
+		t.Fatal(err)
+	}
+	uids, err := c.SearchSince(time.Now().AddDate(0, 0, -7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bodies, err := c.Fetch(uids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bodies) != len(raws) {
+		t.Fatalf("fetched %d messages, want %d", len(bodies), len(raws))
+	}
+
+	msgs := make([]*Message, len(bodies))
+	for i, b := range bodies {
+		if msgs[i], err = Parse(b); err != nil {
+			t.Fatalf("parsing message %d: %v", i+1, err)
+		}
+	}
+	return msgs
+}
+
+func sentEmails() []models.Email {
+	sent := func(addr, id string) models.Email {
+		return models.Email{Contact: models.Contact{Email: addr}, Status: models.StatusSent, MessageID: id}
+	}
+	carl := sent("carl@corp.io", "<sent-carl@example.com>")
+	carl.FollowUps = []models.FollowUp{{Step: 1, MessageID: "<fu-carl@example.com>"}}
+	return []models.Email{
+		sent("ann@acme.com", "<sent-ann@example.com>"),
+		carl,
+		sent("dana@startup.dev", "<sent-dana@example.com>"),
+		sent("eve@gone.com", "<sent-eve@example.com>"),
+		sent("fay@slow.com", "<sent-fay@example.com>"),
+		{Contact: models.Contact{Email: "gus@draft.com"}, Status: models.StatusDraft},
+	}
+}
+
+func TestParseClassifies(t *testing.T) {
+	tests := []struct {
+		name       string
+		raw        string
+		kind       Kind
+		recipients []string
+		refs       []string
+	}{
+		{"reply", replyByID, KindReply, nil, []string{"<sent-ann@example.com>"}},
+		{"auto-submitted", autoReply, KindAutoReply, nil, []string{"<sent-ann@example.com>"}},
+		{"out of office subject", outOfOffice, KindAutoReply, nil, nil},
+		{"failed DSN", failedDSN, KindBounce, []string{"eve@gone.com"}, []string{"<sent-eve@example.com>"}},
+		{"delayed DSN", delayedDSN, KindAutoReply, nil, []string{"<sent-fay@example.com>"}},
+	}
+
+	raws := make([]string, len(tests))
+	for i, tt := range tests {
+		raws[i] = tt.raw
+	}
+	msgs := fetch(t, raws...)
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := msgs[i]
+			if msg.Kind != tt.kind {
+				t.Errorf("kind = %s, want %s", msg.Kind, tt.kind)
+			}
+			if !slices.Equal(msg.Recipients, tt.recipients) {
+				t.Errorf("recipients = %v, want %v", msg.Recipients, tt.recipients)
+			}
+			if !slices.Equal(msg.References, tt.refs) {
+				t.Errorf("references = %v, want %v", msg.References, tt.refs)
+			}
+		})
+	}
+}
+
+func TestApplyMatchesAndRecords(t *testing.T) {
+	emails := sentEmails()
+	msgs := fetch(t, replyByID, replyToFollowUp, replyBySender, autoReply, outOfOffice, failedDSN, delayedDSN, stranger)
+
+	res := Apply(emails, msgs)
+	if res.Replies != 3 || res.AutoReplies != 3 || res.Bounces != 1 || res.Unmatched != 1 {
+		t.Errorf("result = %+v, want 3 replies, 3 auto-replies, 1 bounce, 1 unmatched", res)
+	}
+	if !slices.Equal(res.Bounced, []string{"eve@gone.com"}) {
+		t.Errorf("bounced = %v, want [eve@gone.com]", res.Bounced)
+	}
+
+	want := map[string]struct {
+		status string
+		inbox  int
+	}{
+		// Matched by In-Reply-To although Bob, not Ann, replied
+		"ann@acme.com": {models.StatusReplied, 2},
+		// Matched by the follow-up's Message-ID in References
+		"carl@corp.io": {models.StatusReplied, 2},
+		// Matched by sender, case-insensitively
+		"dana@startup.dev": {models.StatusReplied, 1},
+		"eve@gone.com":     {models.StatusBounced, 1},
+		// A delay notice is recorded but changes nothing
+		"fay@slow.com":  {models.StatusSent, 1},
+		"gus@draft.com": {models.StatusDraft, 0},
+	}
+	for _, e := range emails {
+		w := want[e.Contact.Email]
+		if e.Status != w.status {
+			t.Errorf("%s: status = %s, want %s", e.Contact.Email, e.Status, w.status)
+		}
+		if len(e.Inbox) != w.inbox {
+			t.Errorf("%s: %d inbox events, want %d", e.Contact.Email, len(e.Inbox), w.inbox)
+		}
+	}
+
+	// Syncing the same window again records nothing new
+	again := Apply(emails, msgs)
+	if again.Replies+again.AutoReplies+again.Bounces != 0 || len(again.Bounced) != 0 {
+		t.Errorf("second apply = %+v, want nothing new", again)
+	}
+
+	path := filepath.Join(t.TempDir(), "ledger.jsonl")
+	led, err := ledger.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, addr := range res.Bounced {
+		if err := led.Suppress(addr); err != nil {
+			t.Fatal(err)
+		}
+	}
+	led.Close()
+
+	led, err = ledger.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer led.Close()
+	if _, ok := led.Suppressed("Eve@gone.com"); !ok {
+		t.Error("eve@gone.com is not suppressed after reopening the ledger")
+	}
+	if err := led.Check("eve@gone.com", false); err == nil {
+		t.Error("Check allows mailing a suppressed address")
+	}
+	for _, addr := range []string{"fay@slow.com", "ann@acme.com"} {
+		if _, ok := led.Suppressed(addr); ok {
+			t.Errorf("%s is suppressed", addr)
+		}
+	}
+}
+
+func TestApplyIgnoresBounceForOtherAddress(t *testing.T) {
+	emails := sentEmails()
+	// A bounce threaded to Ann's email that reports a different recipient,
+	// such as an address her mail is forwarded to
+	raw := strings.NewReplacer("eve@gone.com", "ann.forward@elsewhere.com", "<sent-eve@example.com>", "<sent-ann@example.com>").Replace(failedDSN)
+	res := Apply(emails, fetch(t, raw))
+
+	if res.Bounces != 1 || len(res.Bounced) != 0 {
+		t.Errorf("result = %+v, want a bounce with nothing to suppress", res)
+	}
+	if emails[0].Status != models.StatusSent {
+		t.Errorf("ann@acme.com: status = %s, want %s", emails[0].Status, models.StatusSent)
+	}
+}
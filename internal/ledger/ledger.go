@@ -0,0 +1,216 @@
+package ledger
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// KindBounced marks an entry that suppresses a hard-bounced address.
+const KindBounced = "bounced"
+
+// Entry records a single email delivered to an address, or with Kind set,
+// a suppression of that address.
+type Entry struct {
+	Kind      string    `json:"kind,omitempty"`
+	Email     string    `json:"email"`
+	Domain    string    `json:"domain"`
+	MessageID string    `json:"message_id"`
+	Subject   string    `json:"subject,omitempty"`
+	Campaign  string    `json:"campaign,omitempty"`
+	SentAt    time.Time `json:"sent_at"`
+}
+
+// Ledger is a durable, append-only history of every address ever sent to.
+// It is shared across runs and campaigns so nobody is mailed twice.
+type Ledger struct {
+	mu        sync.Mutex
+	f         *os.File
+	addresses map[string]Entry
+	domains   map[string]Entry
+	bounced   map[string]Entry
+}
+
+func Open(path string) (*Ledger, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("creating ledger directory: %w", err)
+	}
+
+	l := &Ledger{
+		addresses: make(map[string]Entry),
+		domains:   make(map[string]Entry),
+		bounced:   make(map[string]Entry),
+	}
+	if err := l.load(path); err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("opening ledger: %w", err)
+	}
+	l.f = f
+	return l, nil
+}
+
+// Contacted returns the most recent send to addr, if any.
+func (l *Ledger) Contacted(addr string) (Entry, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e, ok := l.addresses[normalize(addr)]
+	return e, ok
+}
+
+// DomainContacted returns the most recent send to any address at addr's domain.
+func (l *Ledger) DomainContacted(addr string) (Entry, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e, ok := l.domains[Domain(addr)]
+	return e, ok
+}
+
+// Suppressed reports whether addr hard-bounced. Suppressed addresses are
+// never mailed again, whatever the resend overrides say.
+func (l *Ledger) Suppressed(addr string) (Entry, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e, ok := l.bounced[normalize(addr)]
+	return e, ok
+}
+
+// Suppress records that addr bounced.
+func (l *Ledger) Suppress(addr string) error {
+	if _, ok := l.Suppressed(addr); ok {
+		return nil
+	}
+	return l.Record(Entry{Kind: KindBounced, Email: addr})
+}
+
+// Check returns an error describing why addr must not be mailed again. When
+// blockDomains is set, any earlier send to the same domain also counts.
+func (l *Ledger) Check(addr string, blockDomains bool) error {
+	if e, ok := l.Suppressed(addr); ok {
+		return fmt.Errorf("%s bounced on %s", addr, e.SentAt.Format(time.DateOnly))
+	}
+	if e, ok := l.Contacted(addr); ok {
+		return fmt.Errorf("%s already contacted on %s (message %s)", addr, e.SentAt.Format(time.DateOnly), e.MessageID)
+	}
+	if blockDomains {
+		if e, ok := l.DomainContacted(addr); ok {
+			return fmt.Errorf("domain %s already contacted via %s on %s", e.Domain, e.Email, e.SentAt.Format(time.DateOnly))
+		}
+	}
+	return nil
+}
+
+func (l *Ledger) Record(e Entry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e.Email = normalize(e.Email)
+	e.Domain = Domain(e.Email)
+	if e.SentAt.IsZero() {
+		e.SentAt = time.Now()
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("marshaling ledger entry: %w", err)
+	}
+	if _, err := l.f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("writing ledger entry: %w", err)
+	}
+	if err := l.f.Sync(); err != nil {
+		return fmt.Errorf("syncing ledger: %w", err)
+	}
+
+	l.add(e)
+	return nil
+}
+
+func (l *Ledger) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.addresses)
+}
+
+func (l *Ledger) Close() error {
+	return l.f.Close()
+}
+
+func Domain(addr string) string {
+	addr = normalize(addr)
+	if i := strings.LastIndex(addr, "@"); i >= 0 {
+		return addr[i+1:]
+	}
+	return ""
+}
+
+func normalize(addr string) string {
+	return strings.ToLower(strings.TrimSpace(addr))
+}
+
+func (l *Ledger) add(e Entry) {
+	if e.Kind == KindBounced {
+		l.bounced[e.Email] = e
+		return
+	}
+	l.addresses[e.Email] = e
+	if e.Domain != "" {
+		l.domains[e.Domain] = e
+	}
+}
+
+func (l *Ledger) load(path string) error {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("opening ledger: %w", err)
+	}
+	defer f.Close()
+
+	// A line that does not parse is only tolerated at the end, where a crash
+	// mid-write leaves it, and is cut off so the next entry starts on a line
+	// of its own. Anywhere else it could hide a send or a suppression, so
+	// loading fails rather than risk mailing someone twice.
+	var torn error
+	var good int64
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		line := sc.Bytes()
+		if len(line) > 0 {
+			if torn != nil {
+				return torn
+			}
+			var e Entry
+			if err := json.Unmarshal(line, &e); err != nil {
+				torn = fmt.Errorf("ledger %s line %d is corrupt: %w", path, n, err)
+				continue
+			}
+			l.add(e)
+		}
+		if torn == nil {
+			good += int64(len(line)) + 1
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("reading ledger: %w", err)
+	}
+	if torn != nil {
+		log.Warn().Err(torn).Msg("dropping torn last line of the ledger")
+		if err := os.Truncate(path, good); err != nil {
+			return fmt.Errorf("repairing ledger: %w", err)
+		}
+	}
+	return nil
+}
@@ -1,6 +1,12 @@
 package models
 
-import "time"
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
 
 type Contact struct {
 	Email   string `json:"email"`
@@ -8,18 +14,225 @@ type Contact struct {
 	Company string `json:"company"`
 	Role    string `json:"role"`
 	URL     string `json:"url"`
+	// Fields holds anything else known about the contact, such as sector,
+	// tags or notes.
+	Fields map[string]string `json:"fields,omitempty"`
+	// Validation is the result of the last check of Email, if any.
+	Validation *Validation `json:"validation,omitempty"`
 }
 
+// Validation verdicts, from best to worst.
+const (
+	VerdictValid   = "valid"
+	VerdictUnknown = "unknown"
+	VerdictRisky   = "risky"
+	VerdictInvalid = "invalid"
+)
+
+// Validation is the verdict on a contact's email address. Unknown means the
+// DNS lookup could not be completed; risky addresses, such as role
+// accounts, may be delivered but rarely reach a person.
+type Validation struct {
+	Verdict    string    `json:"verdict"`
+	Reasons    []string  `json:"reasons,omitempty"`
+	Suggestion string    `json:"suggestion,omitempty"`
+	Role       bool      `json:"role,omitempty"`
+	Disposable bool      `json:"disposable,omitempty"`
+	CheckedAt  time.Time `json:"checked_at"`
+}
+
+// Facts lists the contact's fields as "Label: value" lines sorted by key,
+// skipping blank values. A key such as "funding_stage" is labeled
+// "Funding stage".
+func (c Contact) Facts() []string {
+	keys := make([]string, 0, len(c.Fields))
+	for k, v := range c.Fields {
+		if strings.TrimSpace(v) != "" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	facts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		label := strings.TrimSpace(strings.ReplaceAll(k, "_", " "))
+		if label == "" {
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(label)
+		label = string(unicode.ToUpper(r)) + label[size:]
+		facts = append(facts, label+": "+strings.TrimSpace(c.Fields[k]))
+	}
+	return facts
+}
+
+// ScrapeResult is the content scraped for one contact URL. RobotsSkipped
+// lists pages robots.txt disallowed, which explains thin or missing content.
 type ScrapeResult struct {
-	URL      string `json:"url"`
-	Markdown string `json:"markdown"`
-	Error    string `json:"error,omitempty"`
+	URL           string          `json:"url"`
+	Markdown      string          `json:"markdown"`
+	Signals       *CompanySignals `json:"signals,omitempty"`
+	Pages         []string        `json:"pages,omitempty"`
+	RobotsSkipped []string        `json:"robots_skipped,omitempty"`
+	Error         string          `json:"error,omitempty"`
 }
 
+// CompanySignals are facts extracted from the scraped HTML, independent of
+// how much markdown fits in the prompt.
+type CompanySignals struct {
+	Title        string            `json:"title,omitempty"`
+	Description  string            `json:"description,omitempty"`
+	OpenGraph    map[string]string `json:"open_graph,omitempty"`
+	Organization *Organization     `json:"organization,omitempty"`
+	JobPostings  []JobPosting      `json:"job_postings,omitempty"`
+	TechStack    []string          `json:"tech_stack,omitempty"`
+	OpenRoles    []string          `json:"open_roles,omitempty"`
+	BlogPosts    []string          `json:"blog_posts,omitempty"`
+}
+
+// Organization is a schema.org Organization found in JSON-LD.
+type Organization struct {
+	Name         string   `json:"name,omitempty"`
+	Description  string   `json:"description,omitempty"`
+	URL          string   `json:"url,omitempty"`
+	FoundingDate string   `json:"founding_date,omitempty"`
+	Employees    string   `json:"employees,omitempty"`
+	Location     string   `json:"location,omitempty"`
+	SameAs       []string `json:"same_as,omitempty"`
+}
+
+// JobPosting is a schema.org JobPosting found in JSON-LD.
+type JobPosting struct {
+	Title          string `json:"title"`
+	Location       string `json:"location,omitempty"`
+	EmploymentType string `json:"employment_type,omitempty"`
+	DatePosted     string `json:"date_posted,omitempty"`
+	URL            string `json:"url,omitempty"`
+}
+
+// Facts lists the signals as short lines for a prompt, most useful first.
+func (s *CompanySignals) Facts() []string {
+	if s == nil {
+		return nil
+	}
+	var facts []string
+	add := func(label, value string) {
+		if value != "" {
+			facts = append(facts, label+": "+value)
+		}
+	}
+	list := func(values []string, n int) string {
+		if len(values) > n {
+			values = values[:n]
+		}
+		return strings.Join(values, "; ")
+	}
+
+	if o := s.Organization; o != nil {
+		add("Organization", o.Name)
+		add("About", o.Description)
+		add("Founded", o.FoundingDate)
+		add("Employees", o.Employees)
+		add("Location", o.Location)
+	}
+	add("Site title", s.Title)
+	if s.Organization == nil || s.Organization.Description != s.Description {
+		add("Description", s.Description)
+	}
+	add("Open roles", list(s.OpenRoles, 8))
+	add("Tech stack", strings.Join(s.TechStack, ", "))
+	add("Recent blog posts", list(s.BlogPosts, 5))
+	return facts
+}
+
+// Where an email's company context came from, from most to least specific.
+const (
+	ContextScrape  = "scrape"
+	ContextCache   = "cache"
+	ContextContact = "contact"
+	ContextSearch  = "search"
+	ContextNone    = "none"
+)
+
+// Email statuses.
+const (
+	StatusDraft            = "draft"
+	StatusApproved         = "approved"
+	StatusRejected         = "rejected"
+	StatusSent             = "sent"
+	StatusFailed           = "failed"
+	StatusGenerationFailed = "generation_failed"
+	StatusReplied          = "replied"
+	StatusBounced          = "bounced"
+)
+
 type Email struct {
-	Contact     Contact   `json:"contact"`
+	Contact             Contact        `json:"contact"`
+	Subject             string         `json:"subject"`
+	Body                string         `json:"body"`
+	InferredRole        string         `json:"inferred_role,omitempty"`
+	PersonalizationHook string         `json:"personalization_hook,omitempty"`
+	Confidence          float64        `json:"confidence,omitempty"`
+	ContextSource       string         `json:"context_source,omitempty"`
+	Status              string         `json:"status"`
+	GeneratedAt         time.Time      `json:"generated_at"`
+	MessageID           string         `json:"message_id,omitempty"`
+	SentAt              *time.Time     `json:"sent_at,omitempty"`
+	Error               string         `json:"error,omitempty"`
+	FollowUps           []FollowUp     `json:"follow_ups,omitempty"`
+	Inbox               []InboxEvent   `json:"inbox,omitempty"`
+	Hint                string         `json:"hint,omitempty"`
+	Versions            []EmailVersion `json:"versions,omitempty"`
+}
+
+// EmailVersion is an earlier draft replaced by a regeneration, oldest first.
+type EmailVersion struct {
 	Subject     string    `json:"subject"`
 	Body        string    `json:"body"`
-	Status      string    `json:"status"`
+	Hint        string    `json:"hint,omitempty"`
 	GeneratedAt time.Time `json:"generated_at"`
 }
+
+// Revise replaces the draft with fresh, regenerated following hint, and
+// keeps the previous draft in Versions. Hint records the instruction behind
+// the current draft. The result needs approving again.
+func (e *Email) Revise(fresh *Email, hint string) {
+	if e.Body != "" {
+		e.Versions = append(e.Versions, EmailVersion{
+			Subject:     e.Subject,
+			Body:        e.Body,
+			Hint:        e.Hint,
+			GeneratedAt: e.GeneratedAt,
+		})
+	}
+	e.Subject = fresh.Subject
+	e.Body = fresh.Body
+	e.InferredRole = fresh.InferredRole
+	e.PersonalizationHook = fresh.PersonalizationHook
+	e.Confidence = fresh.Confidence
+	e.ContextSource = fresh.ContextSource
+	e.GeneratedAt = fresh.GeneratedAt
+	e.Hint = hint
+	e.Error = ""
+	e.Status = StatusDraft
+}
+
+// FollowUp is one step of a follow-up sequence, sent as a reply in the
+// original email's thread. SentAt is nil while it is still a draft.
+type FollowUp struct {
+	Step      int        `json:"step"`
+	Subject   string     `json:"subject"`
+	Body      string     `json:"body"`
+	MessageID string     `json:"message_id,omitempty"`
+	SentAt    *time.Time `json:"sent_at,omitempty"`
+}
+
+// InboxEvent is an incoming message matched to a sent email: a reply, an
+// auto-reply or a bounce.
+type InboxEvent struct {
+	Kind       string    `json:"kind"`
+	From       string    `json:"from"`
+	Subject    string    `json:"subject"`
+	MessageID  string    `json:"message_id,omitempty"`
+	ReceivedAt time.Time `json:"received_at"`
+}
@@ -0,0 +1,76 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Bucket is a token bucket refilled continuously at perMinute tokens per
+// minute, holding at most one minute's worth. A nil *Bucket never blocks.
+type Bucket struct {
+	mu       sync.Mutex
+	capacity float64
+	tokens   float64
+	rate     float64 // tokens per second
+	last     time.Time
+}
+
+// NewBucket returns nil when perMinute is not positive, meaning unlimited.
+func NewBucket(perMinute int) *Bucket {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &Bucket{
+		capacity: float64(perMinute),
+		tokens:   float64(perMinute),
+		rate:     float64(perMinute) / 60,
+		last:     time.Now(),
+	}
+}
+
+// Wait blocks until n tokens are available and takes them. Requests larger
+// than the bucket are clamped to its capacity so they cannot block forever.
+func (b *Bucket) Wait(ctx context.Context, n int) error {
+	if b == nil {
+		return ctx.Err()
+	}
+
+	for {
+		delay := b.reserve(float64(n))
+		if delay == 0 {
+			return nil
+		}
+
+		t := time.NewTimer(delay)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// reserve takes n tokens if available, otherwise reports how long to wait.
+func (b *Bucket) reserve(n float64) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if n > b.capacity {
+		n = b.capacity
+	}
+
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+	b.last = now
+
+	if b.tokens >= n {
+		b.tokens -= n
+		return 0
+	}
+	return time.Duration((n - b.tokens) / b.rate * float64(time.Second))
+}
@@ -0,0 +1,79 @@
+package review
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/dantezy/cold-send0r-bot/internal/models"
+)
+
+const subjectPrefix = "Subject:"
+
+// Editor returns the user's preferred editor from $VISUAL or $EDITOR.
+func Editor() string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if e := strings.TrimSpace(os.Getenv(env)); e != "" {
+			return e
+		}
+	}
+	return "vi"
+}
+
+// Edit opens email's subject and body in editor and reads them back. The
+// file starts with a "Subject:" line, then a blank line, then the body.
+// editor may include arguments, e.g. "code --wait".
+func Edit(editor string, email *models.Email) error {
+	f, err := os.CreateTemp("", "send0r-*.txt")
+	if err != nil {
+		return fmt.Errorf("creating temp file: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	_, err = fmt.Fprintf(f, "%s %s\n\n%s\n", subjectPrefix, email.Subject, email.Body)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("writing temp file: %w", err)
+	}
+
+	args := strings.Fields(editor)
+	if len(args) == 0 {
+		return fmt.Errorf("no editor configured (set $EDITOR)")
+	}
+	cmd := exec.Command(args[0], append(args[1:], f.Name())...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("running %s: %w", args[0], err)
+	}
+
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		return fmt.Errorf("reading edited email: %w", err)
+	}
+	subject, body, err := parseEdited(string(data))
+	if err != nil {
+		return err
+	}
+	email.Subject = subject
+	email.Body = body
+	return nil
+}
+
+func parseEdited(text string) (subject, body string, err error) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	first, rest, _ := strings.Cut(text, "\n")
+	if !strings.HasPrefix(first, subjectPrefix) {
+		return "", "", fmt.Errorf("edited email must start with a %q line", subjectPrefix)
+	}
+	subject = strings.TrimSpace(strings.TrimPrefix(first, subjectPrefix))
+	body = strings.TrimSpace(rest)
+	if subject == "" || body == "" {
+		return "", "", fmt.Errorf("edited email has an empty subject or body")
+	}
+	return subject, body, nil
+}
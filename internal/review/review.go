@@ -0,0 +1,222 @@
+package review
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/dantezy/cold-send0r-bot/internal/models"
+)
+
+const excerptLength = 600
+
+// contextNotes warn about drafts written without a fresh scrape.
+var contextNotes = map[string]string{
+	models.ContextCache:   "earlier scrape (this one failed)",
+	models.ContextContact: "contact fields only, website not scraped",
+	models.ContextSearch:  "web search snippets, website not scraped",
+	models.ContextNone:    "none, expect a generic email",
+}
+
+// Reviewer walks a reviewer through drafts one at a time on a terminal.
+// Decisions are written back to the email's Status and saved immediately,
+// so quitting part-way keeps everything decided so far.
+type Reviewer struct {
+	In     io.Reader
+	Out    io.Writer
+	Editor string
+
+	// Scrape returns the scraped website for a contact, or nil.
+	Scrape func(c models.Contact) *models.ScrapeResult
+	// Regenerate rewrites email following a one-line instruction.
+	Regenerate func(ctx context.Context, email *models.Email, hint string) (*models.Email, error)
+	// Save persists all emails after every change.
+	Save func() error
+
+	in *bufio.Reader
+}
+
+// Summary counts the decisions made in one session.
+type Summary struct {
+	Approved, Rejected, Edited, Regenerated, Remaining int
+}
+
+// Run reviews emails[i] for each i in queue, in order.
+func (r *Reviewer) Run(ctx context.Context, emails []models.Email, queue []int) (Summary, error) {
+	var sum Summary
+	r.in = bufio.NewReader(r.In)
+
+	for pos := 0; pos < len(queue); {
+		email := &emails[queue[pos]]
+		r.show(email, pos+1, len(queue))
+
+		choice, err := r.prompt(ctx, "[a]pprove [r]eject [e]dit [g]enerate again [s]kip [b]ack [q]uit > ")
+		if err != nil {
+			sum.Remaining = countDrafts(emails, queue)
+			return sum, err
+		}
+
+		switch strings.ToLower(choice) {
+		case "a", "approve":
+			email.Status = models.StatusApproved
+			sum.Approved++
+			pos++
+		case "r", "reject":
+			email.Status = models.StatusRejected
+			sum.Rejected++
+			pos++
+		case "e", "edit":
+			if err := Edit(r.Editor, email); err != nil {
+				fmt.Fprintf(r.Out, "\nedit failed: %v\n", err)
+				continue
+			}
+			sum.Edited++
+		case "g", "generate", "regenerate":
+			hint, err := r.prompt(ctx, "Instruction for the rewrite: ")
+			if err != nil {
+				sum.Remaining = countDrafts(emails, queue)
+				return sum, err
+			}
+			fmt.Fprintln(r.Out, "Regenerating...")
+			fresh, err := r.Regenerate(ctx, email, hint)
+			if err != nil {
+				fmt.Fprintf(r.Out, "\nregeneration failed: %v\n", err)
+				continue
+			}
+			email.Revise(fresh, hint)
+			sum.Regenerated++
+		case "s", "skip", "":
+			pos++
+			continue
+		case "b", "back":
+			if pos > 0 {
+				pos--
+			}
+			continue
+		case "q", "quit":
+			sum.Remaining = countDrafts(emails, queue)
+			return sum, nil
+		default:
+			fmt.Fprintf(r.Out, "\nunknown choice %q\n", choice)
+			continue
+		}
+
+		if err := r.Save(); err != nil {
+			return sum, err
+		}
+	}
+
+	sum.Remaining = countDrafts(emails, queue)
+	return sum, nil
+}
+
+func (r *Reviewer) show(email *models.Email, n, total int) {
+	w := r.Out
+	c := email.Contact
+
+	fmt.Fprintf(w, "\n%s %d/%d %s\n", strings.Repeat("─", 4), n, total, strings.Repeat("─", 60))
+	fmt.Fprintf(w, "To:       %s <%s>\n", c.Name, c.Email)
+	fmt.Fprintf(w, "Company:  %s\n", strings.TrimSpace(strings.Join(nonEmpty(c.Role, c.Company), " at ")))
+	if c.URL != "" {
+		fmt.Fprintf(w, "Website:  %s\n", c.URL)
+	}
+	if v := c.Validation; v != nil && v.Verdict != models.VerdictValid {
+		fmt.Fprintf(w, "Address:  %s (%s)\n", v.Verdict, strings.Join(v.Reasons, "; "))
+	}
+	if facts := c.Facts(); len(facts) > 0 {
+		fmt.Fprintf(w, "Fields:   %s\n", strings.Join(facts, "; "))
+	}
+	status := email.Status
+	if email.Confidence > 0 {
+		status += fmt.Sprintf("  (confidence %.2f)", email.Confidence)
+	}
+	fmt.Fprintf(w, "Status:   %s\n", status)
+	if email.PersonalizationHook != "" {
+		fmt.Fprintf(w, "Hook:     %s\n", email.PersonalizationHook)
+	}
+	if note, ok := contextNotes[email.ContextSource]; ok {
+		fmt.Fprintf(w, "Context:  %s\n", note)
+	}
+
+	fmt.Fprintln(w, "\nWebsite excerpt:")
+	fmt.Fprintln(w, indent(excerpt(r.Scrape(c)), "  │ "))
+
+	fmt.Fprintf(w, "\nSubject: %s\n\n", email.Subject)
+	fmt.Fprintln(w, indent(email.Body, "  "))
+	fmt.Fprintln(w)
+}
+
+type readResult struct {
+	line string
+	err  error
+}
+
+// prompt reads one line, returning early when ctx is cancelled so Ctrl-C
+// ends the session instead of waiting for Enter. Input is read only while
+// a prompt waits, so nothing competes with $EDITOR for the terminal; a read
+// abandoned by Ctrl-C is left behind as the session is over.
+func (r *Reviewer) prompt(ctx context.Context, label string) (string, error) {
+	fmt.Fprint(r.Out, label)
+	done := make(chan readResult, 1)
+	go func() {
+		line, err := r.in.ReadString('\n')
+		done <- readResult{line, err}
+	}()
+	select {
+	case <-ctx.Done():
+		fmt.Fprintln(r.Out)
+		return "", ctx.Err()
+	case res := <-done:
+		if res.err != nil && res.line == "" {
+			return "", res.err
+		}
+		return strings.TrimSpace(res.line), nil
+	}
+}
+
+func excerpt(s *models.ScrapeResult) string {
+	switch {
+	case s == nil:
+		return "(not scraped)"
+	case s.Markdown == "" && s.Error != "":
+		return "(scrape failed: " + s.Error + ")"
+	case s.Markdown == "":
+		return "(no content)"
+	}
+	text := strings.TrimSpace(s.Markdown)
+	runes := []rune(text)
+	if len(runes) > excerptLength {
+		text = string(runes[:excerptLength]) + "…"
+	}
+	return text
+}
+
+func indent(s, prefix string) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	for i := range lines {
+		lines[i] = prefix + lines[i]
+	}
+	return strings.Join(lines, "\n")
+}
+
+func nonEmpty(values ...string) []string {
+	var out []string
+	for _, v := range values {
+		if v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func countDrafts(emails []models.Email, queue []int) int {
+	n := 0
+	for _, i := range queue {
+		if emails[i].Status == models.StatusDraft {
+			n++
+		}
+	}
+	return n
+}
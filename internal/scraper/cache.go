@@ -0,0 +1,96 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/dantezy/cold-send0r-bot/internal/cache"
+	"github.com/dantezy/cold-send0r-bot/internal/config"
+	"github.com/dantezy/cold-send0r-bot/internal/models"
+)
+
+// Cache namespaces: whole scrape results, and raw pages kept for
+// ETag/Last-Modified revalidation.
+const (
+	resultsNamespace = "results"
+	pagesNamespace   = "pages"
+)
+
+// CachedScraper serves results from a disk cache while they are within the
+// TTL and stores every successful scrape. Failed scrapes are not cached.
+type CachedScraper struct {
+	inner   Scraper
+	cache   *cache.Cache
+	variant string
+}
+
+// NewCachedScraper wraps inner. Results are keyed by normalized URL plus the
+// settings that change their content, so enabling crawling or raising
+// max_content_length does not serve stale single-page results.
+func NewCachedScraper(inner Scraper, c *cache.Cache, cfg config.ScraperConfig) *CachedScraper {
+	return &CachedScraper{inner: inner, cache: c, variant: variant(cfg)}
+}
+
+func variant(cfg config.ScraperConfig) string {
+	v := fmt.Sprintf("%s|max=%d", cfg.Provider, cfg.MaxContentLength)
+	if cfg.Crawl.Enabled {
+		v += fmt.Sprintf("|crawl=%d/%d", cfg.Crawl.MaxPages, cfg.Crawl.MaxDepth)
+	}
+	return v
+}
+
+// Previous returns the last successful scrape of url stored in c under the
+// current settings, however old, or nil. It backs up a scrape that failed.
+func Previous(c *cache.Cache, cfg config.ScraperConfig, url string) (*models.ScrapeResult, time.Time) {
+	var result models.ScrapeResult
+	storedAt, ok := c.Peek(resultsNamespace, cache.NormalizeURL(url)+"|"+variant(cfg), &result)
+	if !ok || result.Markdown == "" {
+		return nil, time.Time{}
+	}
+	result.URL = url
+	return &result, storedAt
+}
+
+func (s *CachedScraper) Scrape(ctx context.Context, url string) (*models.ScrapeResult, error) {
+	if result, ok := s.lookup(url); ok {
+		log.Debug().Str("url", url).Msg("scrape cache hit")
+		return result, nil
+	}
+
+	result, err := s.inner.Scrape(ctx, url)
+	if err != nil || result == nil || result.Error != "" {
+		return result, err
+	}
+	if err := s.cache.Put(resultsNamespace, s.key(url), result); err != nil {
+		log.Warn().Str("url", url).Err(err).Msg("could not cache scrape result")
+	}
+	return result, nil
+}
+
+func (s *CachedScraper) Close() error {
+	return s.inner.Close()
+}
+
+// Cached reports whether url can be answered from the cache, letting the
+// pool skip the per-host delay.
+func (s *CachedScraper) Cached(url string) bool {
+	_, ok := s.lookup(url)
+	return ok
+}
+
+func (s *CachedScraper) lookup(url string) (*models.ScrapeResult, bool) {
+	var result models.ScrapeResult
+	storedAt, ok := s.cache.Get(resultsNamespace, s.key(url), &result)
+	if !ok || !s.cache.Fresh(storedAt) {
+		return nil, false
+	}
+	result.URL = url
+	return &result, true
+}
+
+func (s *CachedScraper) key(url string) string {
+	return cache.NormalizeURL(url) + "|" + s.variant
+}
@@ -1,7 +1,9 @@
 package scraper
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 	"net/url"
 	"strings"
 	"time"
@@ -11,65 +13,157 @@ import (
 	"github.com/gocolly/colly/v2"
 	"github.com/rs/zerolog/log"
 
+	"github.com/dantezy/cold-send0r-bot/internal/budget"
+	"github.com/dantezy/cold-send0r-bot/internal/cache"
 	"github.com/dantezy/cold-send0r-bot/internal/config"
 	"github.com/dantezy/cold-send0r-bot/internal/models"
 )
 
 type CollyRodScraper struct {
-	cfg         config.ScraperConfig
-	rateLimiter *time.Ticker
+	cfg      config.ScraperConfig
+	pages    *cache.Cache
+	robots   *robots
+	browsers *browserPool
+}
+
+// cachedPage is a fetched page kept for conditional requests.
+type cachedPage struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+	Body         string `json:"body"`
 }
 
 func NewCollyRodScraper(cfg config.ScraperConfig) *CollyRodScraper {
-	return &CollyRodScraper{
-		cfg:         cfg,
-		rateLimiter: time.NewTicker(time.Duration(cfg.RateLimitMs) * time.Millisecond),
+	s := &CollyRodScraper{cfg: cfg, browsers: newBrowserPool(cfg.Concurrency)}
+	if !cfg.IgnoreRobots {
+		s.robots = newRobots(cfg)
 	}
+	return s
 }
 
-func (s *CollyRodScraper) Scrape(url string) (*models.ScrapeResult, error) {
-	<-s.rateLimiter.C
-
+func (s *CollyRodScraper) Scrape(ctx context.Context, url string) (*models.ScrapeResult, error) {
 	result := &models.ScrapeResult{URL: url}
 
-	md, err := s.scrapeWithColly(url)
+	home := s.scrapePage(ctx, url, s.cfg.RodFallback)
+	if home.blocked {
+		result.Error = "disallowed by robots.txt"
+		result.RobotsSkipped = []string{url}
+		return result, nil
+	}
+	if home.markdown == "" {
+		result.Error = "no content extracted"
+		return result, nil
+	}
+
+	if s.cfg.Crawl.Enabled {
+		s.crawl(ctx, home, result)
+		return result, nil
+	}
+
+	result.Markdown = budget.Truncate(home.markdown, s.cfg.MaxContentLength)
+	result.Signals = extractSignals([]crawledPage{{"Home", home}})
+	return result, nil
+}
+
+// Close shuts down the headless browser, if one was started.
+func (s *CollyRodScraper) Close() error {
+	return s.browsers.close()
+}
+
+// page is one fetched page; html is kept so links can be followed.
+// blocked pages were not fetched because robots.txt disallows them.
+type page struct {
+	url      string
+	html     string
+	markdown string
+	blocked  bool
+}
+
+// scrapePage fetches url with colly, retrying in a headless browser when
+// rodFallback is set and colly got too little content.
+func (s *CollyRodScraper) scrapePage(ctx context.Context, url string, rodFallback bool) page {
+	p := page{url: url}
+	if !s.robots.allowed(ctx, url) {
+		log.Info().Str("url", url).Msg("skipping, disallowed by robots.txt")
+		p.blocked = true
+		return p
+	}
+
+	html, err := s.fetchWithColly(ctx, url)
+	if err == nil {
+		p.html = html
+		p.markdown, err = htmlToMarkdown(html, url)
+	}
 	if err != nil {
 		log.Warn().Str("url", url).Err(err).Msg("colly scrape failed")
 	}
 
-	if len(md) < 200 && s.cfg.RodFallback {
-		log.Info().Str("url", url).Int("colly_len", len(md)).Msg("thin content, falling back to rod")
-		rodMd, rodErr := s.scrapeWithRod(url)
-		if rodErr != nil {
-			log.Warn().Str("url", url).Err(rodErr).Msg("rod scrape failed")
+	if len(p.markdown) < 200 && rodFallback && ctx.Err() == nil {
+		log.Info().Str("url", url).Int("colly_len", len(p.markdown)).Msg("thin content, falling back to rod")
+		rodHTML, err := s.fetchWithRod(ctx, url)
+		if err == nil {
+			var md string
+			if md, err = htmlToMarkdown(rodHTML, url); err == nil && len(md) > len(p.markdown) {
+				p.html, p.markdown = rodHTML, md
+			}
 		}
-		if len(rodMd) > len(md) {
-			md = rodMd
+		if err != nil {
+			log.Warn().Str("url", url).Err(err).Msg("rod scrape failed")
 		}
 	}
 
-	if md == "" {
-		result.Error = "no content extracted"
-		return result, nil
-	}
-
-	result.Markdown = truncate(md, s.cfg.MaxContentLength)
-	return result, nil
+	return p
 }
 
-func (s *CollyRodScraper) scrapeWithColly(url string) (string, error) {
-	var htmlContent string
+// fetchWithColly GETs url. With a page cache, a page fetched before is
+// requested conditionally and a 304 reuses the stored body.
+func (s *CollyRodScraper) fetchWithColly(ctx context.Context, url string) (string, error) {
+	var htmlContent, etag, lastModified string
+	var status int
+
+	var cached cachedPage
+	revalidate := false
+	if s.pages != nil {
+		_, ok := s.pages.Get(pagesNamespace, cache.NormalizeURL(url), &cached)
+		revalidate = ok && (cached.ETag != "" || cached.LastModified != "")
+	}
 
 	c := colly.NewCollector(
 		colly.AllowURLRevisit(),
+		colly.UserAgent(userAgent(s.cfg)),
 	)
+	c.WithTransport(&contextTransport{ctx: ctx, base: http.DefaultTransport})
 	c.SetRequestTimeout(time.Duration(s.cfg.TimeoutMs) * time.Millisecond)
 
+	c.OnRequest(func(r *colly.Request) {
+		identify(*r.Headers, userAgent(s.cfg), s.cfg.Contact)
+		if !revalidate {
+			return
+		}
+		if cached.ETag != "" {
+			r.Headers.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			r.Headers.Set("If-Modified-Since", cached.LastModified)
+		}
+	})
+
 	c.OnResponse(func(r *colly.Response) {
 		htmlContent = string(r.Body)
+		etag = r.Headers.Get("ETag")
+		lastModified = r.Headers.Get("Last-Modified")
+	})
+
+	c.OnError(func(r *colly.Response, _ error) {
+		status = r.StatusCode
 	})
 
 	if err := c.Visit(url); err != nil {
+		if revalidate && status == http.StatusNotModified {
+			log.Debug().Str("url", url).Msg("page not modified, using cached copy")
+			s.storePage(url, cached)
+			return cached.Body, nil
+		}
 		return "", fmt.Errorf("visiting %s: %w", url, err)
 	}
 
@@ -77,7 +171,30 @@ func (s *CollyRodScraper) scrapeWithColly(url string) (string, error) {
 		return "", fmt.Errorf("empty response from %s", url)
 	}
 
-	return htmlToMarkdown(htmlContent, url)
+	if etag != "" || lastModified != "" {
+		s.storePage(url, cachedPage{ETag: etag, LastModified: lastModified, Body: htmlContent})
+	}
+	return htmlContent, nil
+}
+
+func (s *CollyRodScraper) storePage(url string, p cachedPage) {
+	if s.pages == nil {
+		return
+	}
+	if err := s.pages.Put(pagesNamespace, cache.NormalizeURL(url), p); err != nil {
+		log.Warn().Str("url", url).Err(err).Msg("could not cache page")
+	}
+}
+
+// contextTransport ties colly's requests to ctx, which colly itself has no
+// way to pass through.
+type contextTransport struct {
+	ctx  context.Context
+	base http.RoundTripper
+}
+
+func (t *contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.base.RoundTrip(req.WithContext(t.ctx))
 }
 
 func htmlToMarkdown(html, sourceURL string) (string, error) {
@@ -96,10 +213,3 @@ func htmlToMarkdown(html, sourceURL string) (string, error) {
 
 	return strings.TrimSpace(md), nil
 }
-
-func truncate(s string, maxLen int) string {
-	if maxLen <= 0 || len(s) <= maxLen {
-		return s
-	}
-	return s[:maxLen]
-}
@@ -0,0 +1,250 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/rs/zerolog/log"
+
+	"github.com/dantezy/cold-send0r-bot/internal/budget"
+	"github.com/dantezy/cold-send0r-bot/internal/models"
+)
+
+const (
+	defaultCrawlPages = 5
+	defaultCrawlDepth = 1
+)
+
+// sections lists the kinds of pages worth following, in the order they are
+// preferred. A link belongs to a section when a word of its path or anchor
+// text is one of the keywords.
+var sections = []struct {
+	title    string
+	keywords []string
+}{
+	{"Careers", []string{"careers", "career", "jobs", "job", "join", "hiring", "positions", "openings", "vacancies"}},
+	{"Engineering", []string{"engineering", "tech", "technology", "developers", "developer", "opensource"}},
+	{"Blog", []string{"blog", "news", "posts", "articles", "insights", "stories"}},
+	{"About", []string{"about", "team", "company", "mission", "story", "culture"}},
+	{"Products", []string{"products", "product", "platform", "features", "solutions", "services"}},
+}
+
+// Extensions of links that never lead to an HTML page.
+var skipExtensions = map[string]bool{
+	".pdf": true, ".jpg": true, ".jpeg": true, ".png": true, ".gif": true, ".svg": true, ".webp": true,
+	".zip": true, ".mp4": true, ".mp3": true, ".xml": true, ".json": true, ".css": true, ".js": true,
+}
+
+type link struct {
+	url     string
+	section int
+}
+
+type crawledPage struct {
+	title string
+	page
+}
+
+// crawl follows same-domain links from home that look like careers, blog,
+// about or product pages, breadth first, within the page and depth budget.
+// The pages are assembled into one markdown document with a section each,
+// and signals are extracted from all of them.
+func (s *CollyRodScraper) crawl(ctx context.Context, home page, result *models.ScrapeResult) {
+	maxPages := s.cfg.Crawl.MaxPages
+	if maxPages <= 0 {
+		maxPages = defaultCrawlPages
+	}
+	maxDepth := s.cfg.Crawl.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = defaultCrawlDepth
+	}
+	host := hostOf(home.url)
+	interval := time.Duration(s.cfg.RateLimitMs) * time.Millisecond
+
+	pages := []crawledPage{{"Home", home}}
+	visited := map[string]bool{canonical(home.url): true}
+	frontier := []page{home}
+
+	for depth := 1; depth <= maxDepth && len(pages) < maxPages; depth++ {
+		var candidates []link
+		for _, p := range frontier {
+			candidates = append(candidates, links(p, host)...)
+		}
+
+		var next []page
+		for _, l := range pick(candidates, visited) {
+			if len(pages) >= maxPages || ctx.Err() != nil {
+				break
+			}
+			if !s.robots.allowed(ctx, l.url) {
+				log.Debug().Str("url", l.url).Msg("not crawling, disallowed by robots.txt")
+				result.RobotsSkipped = append(result.RobotsSkipped, l.url)
+				continue
+			}
+			if sleep(ctx, interval) != nil {
+				break
+			}
+			p := s.scrapePage(ctx, l.url, false)
+			if p.markdown == "" {
+				continue
+			}
+			log.Debug().Str("url", l.url).Int("depth", depth).Str("section", sections[l.section].title).Msg("crawled page")
+			pages = append(pages, crawledPage{sections[l.section].title, p})
+			next = append(next, p)
+		}
+		frontier = next
+	}
+
+	result.Markdown = assemble(pages, s.cfg.MaxContentLength)
+	result.Signals = extractSignals(pages)
+	for _, p := range pages {
+		result.Pages = append(result.Pages, p.url)
+	}
+}
+
+// links returns the same-host links on p that match a section.
+func links(p page, host string) []link {
+	if p.html == "" {
+		return nil
+	}
+	base, err := url.Parse(p.url)
+	if err != nil {
+		return nil
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(p.html))
+	if err != nil {
+		return nil
+	}
+
+	var out []link
+	doc.Find("a[href]").Each(func(_ int, a *goquery.Selection) {
+		href, _ := a.Attr("href")
+		u, err := base.Parse(strings.TrimSpace(href))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || hostOf(u.String()) != host {
+			return
+		}
+		if skipExtensions[strings.ToLower(path.Ext(u.Path))] {
+			return
+		}
+		u.Fragment = ""
+		if section, ok := classify(u.Path, a.Text()); ok {
+			out = append(out, link{url: u.String(), section: section})
+		}
+	})
+	return out
+}
+
+func classify(urlPath, text string) (int, bool) {
+	words := make(map[string]bool)
+	for _, w := range strings.FieldsFunc(strings.ToLower(urlPath+" "+text), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	}) {
+		words[w] = true
+	}
+	for i, sec := range sections {
+		for _, k := range sec.keywords {
+			if words[k] {
+				return i, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// pick dedupes candidates against visited and orders them round-robin
+// across sections, shallowest paths first, so a small budget still covers
+// careers, blog and about pages rather than ten blog posts.
+func pick(candidates []link, visited map[string]bool) []link {
+	bySection := make([][]link, len(sections))
+	for _, l := range candidates {
+		key := canonical(l.url)
+		if visited[key] {
+			continue
+		}
+		visited[key] = true
+		bySection[l.section] = append(bySection[l.section], l)
+	}
+	for _, ls := range bySection {
+		sort.SliceStable(ls, func(i, j int) bool {
+			return strings.Count(ls[i].url, "/") < strings.Count(ls[j].url, "/")
+		})
+	}
+
+	var out []link
+	for round := 0; ; round++ {
+		added := false
+		for _, ls := range bySection {
+			if round < len(ls) {
+				out = append(out, ls[round])
+				added = true
+			}
+		}
+		if !added {
+			return out
+		}
+	}
+}
+
+// canonical identifies a URL for dedup, ignoring scheme, www, query and a
+// trailing slash.
+func canonical(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return hostOf(rawURL) + strings.TrimSuffix(u.EscapedPath(), "/")
+}
+
+// assemble joins pages into one markdown document with a heading per page,
+// sharing maxLen between them. Short pages leave their unused share to the
+// longer ones.
+func assemble(pages []crawledPage, maxLen int) string {
+	bodies := make([]string, len(pages))
+	for i, p := range pages {
+		bodies[i] = p.markdown
+	}
+
+	if maxLen > 0 {
+		order := make([]int, len(pages))
+		for i := range order {
+			order[i] = i
+		}
+		sort.Slice(order, func(a, b int) bool { return len(bodies[order[a]]) < len(bodies[order[b]]) })
+
+		remaining := maxLen
+		for n, i := range order {
+			share := remaining / (len(order) - n)
+			bodies[i] = budget.Truncate(bodies[i], share)
+			remaining -= len(bodies[i])
+		}
+	}
+
+	var b strings.Builder
+	for i, p := range pages {
+		if i > 0 {
+			b.WriteString("\n\n")
+		}
+		fmt.Fprintf(&b, "# %s (%s)\n\n%s", p.title, p.url, bodies[i])
+	}
+	return b.String()
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -1,31 +1,53 @@
 package scraper
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/rs/zerolog/log"
+
+	"github.com/dantezy/cold-send0r-bot/internal/budget"
 	"github.com/dantezy/cold-send0r-bot/internal/config"
 	"github.com/dantezy/cold-send0r-bot/internal/models"
 )
 
+const (
+	firecrawlEndpoint = "https://api.firecrawl.dev/v1/scrape"
+	// firecrawlHost is what the pool rate limits on for this provider:
+	// every scrape is a call to the API, whatever the site.
+	firecrawlHost = "api.firecrawl.dev"
+	// firecrawlRetries is how often a rate-limited call is retried.
+	firecrawlRetries = 3
+)
+
 type FirecrawlScraper struct {
-	cfg         config.ScraperConfig
-	client      *http.Client
-	rateLimiter *time.Ticker
+	cfg    config.ScraperConfig
+	client *http.Client
+	robots *robots
 }
 
 func NewFirecrawlScraper(cfg config.ScraperConfig) *FirecrawlScraper {
-	return &FirecrawlScraper{
+	s := &FirecrawlScraper{
 		cfg: cfg,
 		client: &http.Client{
 			Timeout: time.Duration(cfg.TimeoutMs) * time.Millisecond,
 		},
-		rateLimiter: time.NewTicker(time.Duration(cfg.RateLimitMs) * time.Millisecond),
 	}
+	if !cfg.IgnoreRobots {
+		s.robots = newRobots(cfg)
+	}
+	return s
+}
+
+func (s *FirecrawlScraper) Close() error {
+	return nil
 }
 
 type firecrawlRequest struct {
@@ -37,40 +59,64 @@ type firecrawlResponse struct {
 	Success bool `json:"success"`
 	Data    struct {
 		Markdown string `json:"markdown"`
+		RawHTML  string `json:"rawHtml"`
 	} `json:"data"`
 }
 
-func (s *FirecrawlScraper) Scrape(url string) (*models.ScrapeResult, error) {
-	<-s.rateLimiter.C
-
+func (s *FirecrawlScraper) Scrape(ctx context.Context, url string) (*models.ScrapeResult, error) {
 	result := &models.ScrapeResult{URL: url}
 
+	// Firecrawl fetches the page on our behalf, so the site's robots.txt
+	// still applies
+	if !s.robots.allowed(ctx, url) {
+		log.Info().Str("url", url).Msg("skipping, disallowed by robots.txt")
+		result.Error = "disallowed by robots.txt"
+		result.RobotsSkipped = []string{url}
+		return result, nil
+	}
+
 	body, err := json.Marshal(firecrawlRequest{
 		URL:     url,
-		Formats: []string{"markdown"},
+		Formats: []string{"markdown", "rawHtml"},
 	})
 	if err != nil {
 		return nil, fmt.Errorf("marshaling firecrawl request: %w", err)
 	}
 
-	req, err := http.NewRequest(http.MethodPost, "https://api.firecrawl.dev/v1/scrape", strings.NewReader(string(body)))
-	if err != nil {
-		return nil, fmt.Errorf("creating firecrawl request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+s.cfg.FirecrawlAPIKey)
-
-	resp, err := s.client.Do(req)
-	if err != nil {
-		result.Error = err.Error()
-		return result, nil
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		result.Error = err.Error()
-		return result, nil
+	var resp *http.Response
+	var respBody []byte
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, firecrawlEndpoint, bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("creating firecrawl request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+s.cfg.FirecrawlAPIKey)
+
+		resp, err = s.client.Do(req)
+		if err != nil {
+			result.Error = err.Error()
+			return result, nil
+		}
+		respBody, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			result.Error = err.Error()
+			return result, nil
+		}
+		if resp.StatusCode != http.StatusTooManyRequests || attempt == firecrawlRetries {
+			break
+		}
+
+		wait := s.backoff(attempt, resp.Header.Get("Retry-After"))
+		log.Warn().Str("url", url).Dur("backoff", wait).Msg("firecrawl rate limited, retrying")
+		t := time.NewTimer(wait)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return nil, ctx.Err()
+		}
 	}
 
 	if resp.StatusCode != http.StatusOK {
@@ -90,6 +136,18 @@ func (s *FirecrawlScraper) Scrape(url string) (*models.ScrapeResult, error) {
 		return result, nil
 	}
 
-	result.Markdown = truncate(md, s.cfg.MaxContentLength)
+	result.Markdown = budget.Truncate(md, s.cfg.MaxContentLength)
+	result.Signals = extractSignals([]crawledPage{{"Home", page{url: url, html: fcResp.Data.RawHTML, markdown: md}}})
 	return result, nil
 }
+
+// backoff is how long to wait before retrying a rate-limited call: the
+// server's Retry-After in seconds, or else rate_limit_ms (at least a second)
+// doubled on each attempt.
+func (s *FirecrawlScraper) backoff(attempt int, retryAfter string) time.Duration {
+	if secs, err := strconv.Atoi(strings.TrimSpace(retryAfter)); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	base := max(time.Duration(s.cfg.RateLimitMs)*time.Millisecond, time.Second)
+	return base << attempt
+}
@@ -0,0 +1,146 @@
+package scraper
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dantezy/cold-send0r-bot/internal/config"
+	"github.com/dantezy/cold-send0r-bot/internal/models"
+)
+
+// Pool runs a Scraper over many URLs with bounded concurrency. Requests to
+// the same host are spaced at least rate_limit_ms apart; different hosts
+// proceed in parallel. With the Firecrawl provider every request goes to
+// its API, so all of them are spaced.
+type Pool struct {
+	scraper     Scraper
+	concurrency int
+	hosts       *hostLimiter
+	hostOf      func(url string) string
+}
+
+func NewPool(s Scraper, cfg config.ScraperConfig) *Pool {
+	concurrency := cfg.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	p := &Pool{
+		scraper:     s,
+		concurrency: concurrency,
+		hosts:       newHostLimiter(time.Duration(cfg.RateLimitMs) * time.Millisecond),
+		hostOf:      hostOf,
+	}
+	if cfg.Provider == "firecrawl" {
+		p.hostOf = func(string) string { return firecrawlHost }
+	}
+	return p
+}
+
+// Run scrapes every URL and calls fn as each one completes. Calls to fn are
+// serialized, so it may write to shared state without locking. When ctx is
+// cancelled, queued URLs are dropped, in-flight scrapes are aborted and Run
+// returns ctx.Err() once fn has seen every finished result.
+func (p *Pool) Run(ctx context.Context, urls []string, fn func(url string, result *models.ScrapeResult, err error)) error {
+	type outcome struct {
+		url    string
+		result *models.ScrapeResult
+		err    error
+	}
+
+	jobs := make(chan string)
+	results := make(chan outcome)
+
+	var wg sync.WaitGroup
+	for i := 0; i < p.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for u := range jobs {
+				if c, ok := p.scraper.(cacher); !ok || !c.Cached(u) {
+					if err := p.hosts.wait(ctx, p.hostOf(u)); err != nil {
+						continue
+					}
+				}
+				result, err := p.scraper.Scrape(ctx, u)
+				if ctx.Err() != nil {
+					continue
+				}
+				results <- outcome{u, result, err}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for _, u := range urls {
+			select {
+			case jobs <- u:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	for o := range results {
+		fn(o.url, o.result, o.err)
+	}
+	return ctx.Err()
+}
+
+// cacher is implemented by scrapers that can answer some URLs without
+// touching the network.
+type cacher interface {
+	Cached(url string) bool
+}
+
+type hostLimiter struct {
+	interval time.Duration
+	mu       sync.Mutex
+	next     map[string]time.Time
+}
+
+func newHostLimiter(interval time.Duration) *hostLimiter {
+	return &hostLimiter{interval: interval, next: make(map[string]time.Time)}
+}
+
+// wait blocks until host may be requested again and reserves the next slot.
+func (h *hostLimiter) wait(ctx context.Context, host string) error {
+	h.mu.Lock()
+	now := time.Now()
+	at := h.next[host]
+	if at.Before(now) {
+		at = now
+	}
+	h.next[host] = at.Add(h.interval)
+	h.mu.Unlock()
+
+	delay := time.Until(at)
+	if delay <= 0 {
+		return ctx.Err()
+	}
+
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
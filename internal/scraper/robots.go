@@ -0,0 +1,137 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/temoto/robotstxt"
+
+	"github.com/dantezy/cold-send0r-bot/internal/config"
+)
+
+// DefaultUserAgent identifies the scraper when scraper.user_agent is unset.
+// robots.txt groups for "send0r" apply to it.
+const DefaultUserAgent = "send0r/1.0 (+https://github.com/dante4rt/cold-send0r-bot)"
+
+// robotsLimit caps how much of a robots.txt is read.
+const robotsLimit = 512 << 10
+
+// robots checks URLs against each site's robots.txt, fetched once per
+// scheme and host for the scraper's lifetime.
+type robots struct {
+	userAgent string
+	from      string
+	ignore    []string
+	client    *http.Client
+
+	mu    sync.Mutex
+	sites map[string]*robotstxt.RobotsData
+}
+
+func newRobots(cfg config.ScraperConfig) *robots {
+	var ignore []string
+	for _, d := range cfg.RobotsIgnoreDomains {
+		ignore = append(ignore, strings.TrimPrefix(strings.ToLower(strings.TrimSpace(d)), "www."))
+	}
+	return &robots{
+		userAgent: userAgent(cfg),
+		from:      cfg.Contact,
+		ignore:    ignore,
+		client:    &http.Client{Timeout: time.Duration(cfg.TimeoutMs) * time.Millisecond},
+		sites:     make(map[string]*robotstxt.RobotsData),
+	}
+}
+
+func userAgent(cfg config.ScraperConfig) string {
+	if cfg.UserAgent != "" {
+		return cfg.UserAgent
+	}
+	return DefaultUserAgent
+}
+
+// allowed reports whether rawURL may be fetched. A nil checker, used when
+// ignore_robots is set, allows everything. A robots.txt that cannot be
+// fetched counts as no restrictions, as do 4xx answers; 5xx answers
+// disallow the whole site.
+func (r *robots) allowed(ctx context.Context, rawURL string) bool {
+	if r == nil {
+		return true
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return true
+	}
+	if r.ignored(hostOf(rawURL)) {
+		return true
+	}
+
+	site := u.Scheme + "://" + u.Host
+	r.mu.Lock()
+	data, ok := r.sites[site]
+	r.mu.Unlock()
+
+	if !ok {
+		data, err = r.fetch(ctx, site)
+		if err != nil {
+			log.Debug().Str("site", site).Err(err).Msg("could not read robots.txt, assuming no restrictions")
+			return true
+		}
+		r.mu.Lock()
+		r.sites[site] = data
+		r.mu.Unlock()
+	}
+
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return data.TestAgent(path, r.userAgent)
+}
+
+func (r *robots) ignored(host string) bool {
+	for _, d := range r.ignore {
+		if host == d || strings.HasSuffix(host, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *robots) fetch(ctx context.Context, site string) (*robotstxt.RobotsData, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, site+"/robots.txt", nil)
+	if err != nil {
+		return nil, err
+	}
+	identify(req.Header, r.userAgent, r.from)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, robotsLimit))
+	if err != nil {
+		return nil, fmt.Errorf("reading robots.txt: %w", err)
+	}
+	return robotstxt.FromStatusAndBytes(resp.StatusCode, body)
+}
+
+// identify sets the User-Agent and, when configured, the From header
+// (RFC 9110) so site owners can reach whoever runs the scraper.
+func identify(h http.Header, userAgent, from string) {
+	h.Set("User-Agent", userAgent)
+	if from != "" {
+		h.Set("From", from)
+	}
+}
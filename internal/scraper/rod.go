@@ -1,8 +1,11 @@
 package scraper
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os/user"
+	"sync"
 	"time"
 
 	"github.com/go-rod/rod"
@@ -11,35 +14,172 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
-func (s *CollyRodScraper) scrapeWithRod(url string) (string, error) {
-	path, hasChrome := launcher.LookPath()
-	if !hasChrome {
-		log.Warn().Msg("chrome not found, skipping rod fallback")
-		return "", fmt.Errorf("chrome not found at any standard path")
+var errNoChrome = errors.New("chrome not found at any standard path")
+
+// blockedResources are never loaded by the browser; pages are only read
+// for their text.
+var blockedResources = []proto.NetworkResourceType{
+	proto.NetworkResourceTypeImage,
+	proto.NetworkResourceTypeFont,
+	proto.NetworkResourceTypeMedia,
+}
+
+// browserPool shares one headless Chrome between all rod fallbacks. Chrome
+// is launched on first use, at most `slots` pages are open at once, and a
+// browser that stops responding is replaced on the next request.
+type browserPool struct {
+	slots chan struct{}
+
+	mu       sync.Mutex
+	launcher *launcher.Launcher
+	browser  *rod.Browser
+	router   *rod.HijackRouter
+	noChrome bool
+	closed   bool
+}
+
+func newBrowserPool(size int) *browserPool {
+	if size <= 0 {
+		size = 1
 	}
+	return &browserPool{slots: make(chan struct{}, size)}
+}
 
-	timeout := time.Duration(s.cfg.TimeoutMs) * time.Millisecond
+// acquire waits for a free page slot and returns the running browser,
+// launching it if needed. Every successful acquire must be paired with
+// release.
+func (p *browserPool) acquire(ctx context.Context) (*rod.Browser, error) {
+	select {
+	case p.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
 
-	l := launcher.New().Bin(path).Headless(true)
+	b, err := p.get()
+	if err != nil {
+		<-p.slots
+		return nil, err
+	}
+	return b, nil
+}
+
+func (p *browserPool) release() {
+	<-p.slots
+}
+
+func (p *browserPool) get() (*rod.Browser, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch {
+	case p.closed:
+		return nil, errors.New("browser pool is closed")
+	case p.noChrome:
+		return nil, errNoChrome
+	case p.browser != nil:
+		return p.browser, nil
+	}
+
+	path, ok := launcher.LookPath()
+	if !ok {
+		log.Warn().Msg("chrome not found, skipping rod fallback")
+		p.noChrome = true
+		return nil, errNoChrome
+	}
 
+	l := launcher.New().Bin(path).Headless(true)
 	// Chrome requires --no-sandbox when running as root (common on Linux servers)
 	if u, err := user.Current(); err == nil && u.Uid == "0" {
 		l = l.NoSandbox(true)
 	}
 
-	u, err := l.Launch()
+	controlURL, err := l.Launch()
 	if err != nil {
-		return "", fmt.Errorf("launching browser: %w", err)
+		l.Cleanup()
+		return nil, fmt.Errorf("launching browser: %w", err)
 	}
 
-	browser := rod.New().ControlURL(u).Timeout(timeout)
-	if err := browser.Connect(); err != nil {
-		return "", fmt.Errorf("connecting to browser: %w", err)
+	b := rod.New().ControlURL(controlURL)
+	if err := b.Connect(); err != nil {
+		l.Kill()
+		l.Cleanup()
+		return nil, fmt.Errorf("connecting to browser: %w", err)
 	}
-	defer browser.Close()
 
-	page, err := browser.Page(proto.TargetCreateTarget{URL: url})
+	router := b.HijackRequests()
+	for _, t := range blockedResources {
+		if err := router.Add("*", t, func(h *rod.Hijack) {
+			h.Response.Fail(proto.NetworkErrorReasonBlockedByClient)
+		}); err != nil {
+			log.Warn().Err(err).Msg("could not block browser resources")
+		}
+	}
+	go router.Run()
+
+	log.Debug().Str("bin", path).Msg("launched headless browser")
+	p.launcher, p.browser, p.router = l, b, router
+	return b, nil
+}
+
+// discard shuts b down if it is still the pool's browser, so the next
+// acquire launches a fresh one.
+func (p *browserPool) discard(b *rod.Browser) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.browser != b {
+		return
+	}
+	log.Warn().Msg("headless browser stopped responding, restarting it on next use")
+	_ = p.shutdown()
+}
+
+func (p *browserPool) close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	return p.shutdown()
+}
+
+func (p *browserPool) shutdown() error {
+	if p.browser == nil {
+		return nil
+	}
+	_ = p.router.Stop()
+	err := p.browser.Close()
+	p.launcher.Kill()
+	p.launcher.Cleanup()
+	p.launcher, p.browser, p.router = nil, nil, nil
+	return err
+}
+
+func (s *CollyRodScraper) fetchWithRod(ctx context.Context, url string) (string, error) {
+	b, err := s.browsers.acquire(ctx)
 	if err != nil {
+		return "", err
+	}
+	defer s.browsers.release()
+
+	timeout := time.Duration(s.cfg.TimeoutMs) * time.Millisecond
+
+	page, err := b.Timeout(timeout).Page(proto.TargetCreateTarget{})
+	if err != nil {
+		// A browser that cannot open a tab has crashed or hung
+		s.browsers.discard(b)
+		return "", fmt.Errorf("opening page: %w", err)
+	}
+	// Close with a fresh context so the tab is freed even after Ctrl-C
+	defer page.Context(context.Background()).Close()
+
+	page = page.Context(ctx)
+	if err := page.SetUserAgent(&proto.NetworkSetUserAgentOverride{UserAgent: userAgent(s.cfg)}); err != nil {
+		return "", fmt.Errorf("setting user agent: %w", err)
+	}
+	if s.cfg.Contact != "" {
+		if _, err := page.SetExtraHeaders([]string{"From", s.cfg.Contact}); err != nil {
+			return "", fmt.Errorf("setting From header: %w", err)
+		}
+	}
+	if err := page.Timeout(timeout).Navigate(url); err != nil {
 		return "", fmt.Errorf("navigating to %s: %w", url, err)
 	}
 
@@ -53,10 +193,5 @@ func (s *CollyRodScraper) scrapeWithRod(url string) (string, error) {
 		return "", fmt.Errorf("getting page HTML: %w", err)
 	}
 
-	md, err := htmlToMarkdown(html, url)
-	if err != nil {
-		return "", fmt.Errorf("converting rod html to markdown: %w", err)
-	}
-
-	return md, nil
+	return html, nil
 }
@@ -1,17 +1,34 @@
 package scraper
 
 import (
+	"context"
+
+	"github.com/dantezy/cold-send0r-bot/internal/cache"
 	"github.com/dantezy/cold-send0r-bot/internal/config"
 	"github.com/dantezy/cold-send0r-bot/internal/models"
 )
 
+// Scraper fetches a contact URL. Close releases anything the scraper keeps
+// running between calls, such as a headless browser.
 type Scraper interface {
-	Scrape(url string) (*models.ScrapeResult, error)
+	Scrape(ctx context.Context, url string) (*models.ScrapeResult, error)
+	Close() error
 }
 
-func NewScraper(cfg config.ScraperConfig) Scraper {
+// NewScraper returns the configured scraper. When c is non-nil, results are
+// served from and stored in it, and colly revalidates pages it has seen.
+func NewScraper(cfg config.ScraperConfig, c *cache.Cache) Scraper {
+	var s Scraper
 	if cfg.Provider == "firecrawl" {
-		return NewFirecrawlScraper(cfg)
+		s = NewFirecrawlScraper(cfg)
+	} else {
+		cs := NewCollyRodScraper(cfg)
+		cs.pages = c
+		s = cs
+	}
+
+	if c == nil {
+		return s
 	}
-	return NewCollyRodScraper(cfg)
+	return NewCachedScraper(s, c, cfg)
 }
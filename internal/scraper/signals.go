@@ -0,0 +1,403 @@
+package scraper
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"github.com/dantezy/cold-send0r-bot/internal/models"
+)
+
+const (
+	maxOpenRoles = 20
+	maxBlogPosts = 10
+)
+
+// htmlFingerprints detect site technology from markup or asset URLs.
+var htmlFingerprints = []struct{ name, marker string }{
+	{"Next.js", "/_next/static/"},
+	{"Next.js", "__next_data__"},
+	{"Nuxt", "__nuxt"},
+	{"Gatsby", "___gatsby"},
+	{"React", "data-reactroot"},
+	{"Angular", "ng-version="},
+	{"WordPress", "/wp-content/"},
+	{"Shopify", "cdn.shopify.com"},
+	{"Webflow", "data-wf-site"},
+	{"Framer", "framerusercontent.com"},
+	{"Squarespace", "static.squarespace.com"},
+	{"Wix", "static.wixstatic.com"},
+	{"HubSpot", "js.hs-scripts.com"},
+	{"Segment", "cdn.segment.com"},
+	{"Intercom", "widget.intercom.io"},
+	{"Stripe", "js.stripe.com"},
+}
+
+// textTechnologies are matched in the text of careers and engineering pages
+// and in job postings, where they describe the stack rather than
+// integrations the product offers.
+var textTechnologies = []struct {
+	name string
+	re   *regexp.Regexp
+}{
+	{"Go", regexp.MustCompile(`(?i)\bgolang\b|\bGo\b[,/)]`)},
+	{"Python", regexp.MustCompile(`(?i)\bpython\b`)},
+	{"Java", regexp.MustCompile(`\bJava\b`)},
+	{"Kotlin", regexp.MustCompile(`(?i)\bkotlin\b`)},
+	{"Scala", regexp.MustCompile(`\bScala\b`)},
+	{"JavaScript", regexp.MustCompile(`(?i)\bjavascript\b`)},
+	{"TypeScript", regexp.MustCompile(`(?i)\btypescript\b`)},
+	{"Node.js", regexp.MustCompile(`(?i)\bnode\.?js\b`)},
+	{"Rust", regexp.MustCompile(`\bRust\b`)},
+	{"Ruby", regexp.MustCompile(`\bRuby\b`)},
+	{"Rails", regexp.MustCompile(`\bRails\b`)},
+	{"PHP", regexp.MustCompile(`\bPHP\b`)},
+	{"Elixir", regexp.MustCompile(`(?i)\belixir\b`)},
+	{"C++", regexp.MustCompile(`\bC\+\+`)},
+	{"C#", regexp.MustCompile(`\bC#`)},
+	{".NET", regexp.MustCompile(`\.NET\b`)},
+	{"Swift", regexp.MustCompile(`\bSwift\b`)},
+	{"React", regexp.MustCompile(`\bReact\b`)},
+	{"Vue", regexp.MustCompile(`\bVue(\.js)?\b`)},
+	{"Angular", regexp.MustCompile(`\bAngular\b`)},
+	{"Django", regexp.MustCompile(`(?i)\bdjango\b`)},
+	{"Spring", regexp.MustCompile(`\bSpring Boot\b`)},
+	{"GraphQL", regexp.MustCompile(`(?i)\bgraphql\b`)},
+	{"PostgreSQL", regexp.MustCompile(`(?i)\bpostgres(ql)?\b`)},
+	{"MySQL", regexp.MustCompile(`(?i)\bmysql\b`)},
+	{"MongoDB", regexp.MustCompile(`(?i)\bmongodb\b`)},
+	{"Redis", regexp.MustCompile(`(?i)\bredis\b`)},
+	{"Kafka", regexp.MustCompile(`(?i)\bkafka\b`)},
+	{"Elasticsearch", regexp.MustCompile(`(?i)\belasticsearch\b`)},
+	{"Snowflake", regexp.MustCompile(`\bSnowflake\b`)},
+	{"Spark", regexp.MustCompile(`\bSpark\b`)},
+	{"Airflow", regexp.MustCompile(`\bAirflow\b`)},
+	{"Kubernetes", regexp.MustCompile(`(?i)\bkubernetes\b|\bk8s\b`)},
+	{"Docker", regexp.MustCompile(`(?i)\bdocker\b`)},
+	{"Terraform", regexp.MustCompile(`(?i)\bterraform\b`)},
+	{"AWS", regexp.MustCompile(`\bAWS\b`)},
+	{"GCP", regexp.MustCompile(`\bGCP\b|\bGoogle Cloud\b`)},
+	{"Azure", regexp.MustCompile(`\bAzure\b`)},
+}
+
+// roleWords mark a short heading or link as a job title.
+var roleWords = map[string]bool{
+	"engineer": true, "developer": true, "programmer": true, "designer": true, "manager": true,
+	"scientist": true, "analyst": true, "architect": true, "lead": true, "head": true,
+	"director": true, "intern": true, "specialist": true, "consultant": true, "researcher": true,
+	"recruiter": true, "administrator": true, "sre": true, "devops": true, "writer": true,
+	"marketer": true, "coordinator": true, "representative": true, "executive": true, "officer": true,
+}
+
+// signals collects CompanySignals across the pages of one scrape. Page
+// level fields come from the first page that has them; lists are merged.
+type signals struct {
+	out models.CompanySignals
+}
+
+// extractSignals pulls structured facts out of the crawled pages' HTML.
+// It returns nil when nothing was found.
+func extractSignals(pages []crawledPage) *models.CompanySignals {
+	sig := &signals{}
+	for _, p := range pages {
+		if p.html == "" {
+			continue
+		}
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader(p.html))
+		if err != nil {
+			continue
+		}
+		section := p.title
+		if u, err := url.Parse(p.url); err == nil {
+			if i, ok := classify(u.Path, ""); ok {
+				section = sections[i].title
+			}
+		}
+		sig.page(doc, p, section)
+	}
+
+	o := sig.out
+	if o.Title == "" && o.Description == "" && len(o.OpenGraph) == 0 && o.Organization == nil &&
+		len(o.JobPostings) == 0 && len(o.TechStack) == 0 && len(o.OpenRoles) == 0 && len(o.BlogPosts) == 0 {
+		return nil
+	}
+	return &o
+}
+
+func (sig *signals) page(doc *goquery.Document, p crawledPage, section string) {
+	if sig.out.Title == "" {
+		sig.out.Title = clean(doc.Find("title").First().Text())
+	}
+	sig.meta(doc)
+	sig.jsonLD(doc)
+
+	lower := strings.ToLower(p.html)
+	for _, f := range htmlFingerprints {
+		if strings.Contains(lower, f.marker) {
+			appendUnique(&sig.out.TechStack, f.name, 0)
+		}
+	}
+
+	switch section {
+	case "Careers":
+		sig.technologies(p.markdown)
+		doc.Find("h2, h3, h4, li, a").Each(func(_ int, s *goquery.Selection) {
+			if s.Find("h2, h3, h4, li, a").Length() > 0 {
+				return
+			}
+			if text := clean(s.Text()); isRole(text) {
+				appendUnique(&sig.out.OpenRoles, text, maxOpenRoles)
+			}
+		})
+	case "Engineering":
+		sig.technologies(p.markdown)
+	case "Blog":
+		doc.Find("article h1, article h2, article h3, h2, h3").Each(func(_ int, s *goquery.Selection) {
+			text := clean(s.Text())
+			if n := len(strings.Fields(text)); n >= 3 && n <= 20 && len(text) <= 140 {
+				appendUnique(&sig.out.BlogPosts, text, maxBlogPosts)
+			}
+		})
+	}
+}
+
+// meta reads the description, generator and OpenGraph tags.
+func (sig *signals) meta(doc *goquery.Document) {
+	doc.Find("meta").Each(func(_ int, s *goquery.Selection) {
+		content := clean(s.AttrOr("content", ""))
+		if content == "" {
+			return
+		}
+		name := strings.ToLower(s.AttrOr("name", ""))
+		property := strings.ToLower(s.AttrOr("property", ""))
+
+		switch {
+		case name == "description" && sig.out.Description == "":
+			sig.out.Description = content
+		case name == "generator":
+			// "WordPress 6.4.2" -> "WordPress"
+			appendUnique(&sig.out.TechStack, strings.Fields(content)[0], 0)
+		case strings.HasPrefix(property, "og:"):
+			key := strings.TrimPrefix(property, "og:")
+			if sig.out.OpenGraph == nil {
+				sig.out.OpenGraph = make(map[string]string)
+			}
+			if _, ok := sig.out.OpenGraph[key]; !ok {
+				sig.out.OpenGraph[key] = content
+			}
+		}
+	})
+	if sig.out.Description == "" {
+		sig.out.Description = sig.out.OpenGraph["description"]
+	}
+}
+
+func (sig *signals) jsonLD(doc *goquery.Document) {
+	doc.Find(`script[type="application/ld+json"]`).Each(func(_ int, s *goquery.Selection) {
+		var v any
+		if err := json.Unmarshal([]byte(s.Text()), &v); err != nil {
+			return
+		}
+		for _, obj := range ldObjects(v) {
+			switch {
+			case ldIs(obj, "JobPosting"):
+				sig.jobPosting(obj)
+			case ldIs(obj, "BlogPosting", "Article", "NewsArticle", "TechArticle"):
+				appendUnique(&sig.out.BlogPosts, clean(ldString(obj["headline"])), maxBlogPosts)
+			case ldIs(obj, "Organization", "Corporation", "LocalBusiness", "OnlineBusiness", "NGO"):
+				if sig.out.Organization == nil {
+					sig.out.Organization = organization(obj)
+				}
+			}
+		}
+	})
+}
+
+func (sig *signals) jobPosting(obj map[string]any) {
+	title := clean(ldString(obj["title"]))
+	if title == "" {
+		return
+	}
+	job := models.JobPosting{
+		Title:          title,
+		Location:       jobLocation(obj),
+		EmploymentType: strings.Join(ldStrings(obj["employmentType"]), ", "),
+		DatePosted:     ldString(obj["datePosted"]),
+		URL:            ldString(obj["url"]),
+	}
+	for _, have := range sig.out.JobPostings {
+		if have.Title == job.Title && have.Location == job.Location {
+			return
+		}
+	}
+	sig.out.JobPostings = append(sig.out.JobPostings, job)
+	appendUnique(&sig.out.OpenRoles, title, maxOpenRoles)
+
+	if desc, err := goquery.NewDocumentFromReader(strings.NewReader(ldString(obj["description"]))); err == nil {
+		sig.technologies(desc.Text())
+	}
+}
+
+func (sig *signals) technologies(text string) {
+	for _, t := range textTechnologies {
+		if t.re.MatchString(text) {
+			appendUnique(&sig.out.TechStack, t.name, 0)
+		}
+	}
+}
+
+// appendUnique appends value to list unless it is empty, already present
+// (ignoring case) or the list holds limit entries. A zero limit means no
+// limit.
+func appendUnique(list *[]string, value string, limit int) {
+	if value == "" || (limit > 0 && len(*list) >= limit) {
+		return
+	}
+	for _, have := range *list {
+		if strings.EqualFold(have, value) {
+			return
+		}
+	}
+	*list = append(*list, value)
+}
+
+func isRole(text string) bool {
+	words := strings.Fields(strings.ToLower(text))
+	if len(words) < 2 || len(words) > 10 || len(text) > 80 {
+		return false
+	}
+	for _, w := range words {
+		if roleWords[strings.Trim(w, ",.()-–/")] {
+			return true
+		}
+	}
+	return false
+}
+
+func organization(obj map[string]any) *models.Organization {
+	o := &models.Organization{
+		Name:         clean(ldString(obj["name"])),
+		Description:  clean(ldString(obj["description"])),
+		URL:          ldString(obj["url"]),
+		FoundingDate: ldString(obj["foundingDate"]),
+		Location:     address(obj["address"]),
+		SameAs:       ldStrings(obj["sameAs"]),
+	}
+	switch n := obj["numberOfEmployees"].(type) {
+	case map[string]any:
+		if v := ldString(n["value"]); v != "" {
+			o.Employees = v
+		} else if lo, hi := ldString(n["minValue"]), ldString(n["maxValue"]); lo != "" && hi != "" {
+			o.Employees = lo + "-" + hi
+		}
+	default:
+		o.Employees = ldString(n)
+	}
+	return o
+}
+
+func jobLocation(obj map[string]any) string {
+	var places []string
+	for _, loc := range ldList(obj["jobLocation"]) {
+		if m, ok := loc.(map[string]any); ok {
+			if a := address(m["address"]); a != "" {
+				places = append(places, a)
+			}
+		}
+	}
+	if strings.EqualFold(ldString(obj["jobLocationType"]), "TELECOMMUTE") {
+		places = append(places, "Remote")
+	}
+	return strings.Join(places, "; ")
+}
+
+// address formats a PostalAddress, or a plain string, as "City, Country".
+func address(v any) string {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return clean(ldString(v))
+	}
+	var parts []string
+	for _, k := range []string{"addressLocality", "addressRegion", "addressCountry"} {
+		if s := clean(ldString(m[k])); s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ldObjects flattens a JSON-LD document, including arrays and @graph, into
+// its objects.
+func ldObjects(v any) []map[string]any {
+	var out []map[string]any
+	switch t := v.(type) {
+	case []any:
+		for _, item := range t {
+			out = append(out, ldObjects(item)...)
+		}
+	case map[string]any:
+		out = append(out, t)
+		if g, ok := t["@graph"]; ok {
+			out = append(out, ldObjects(g)...)
+		}
+	}
+	return out
+}
+
+func ldIs(obj map[string]any, types ...string) bool {
+	for _, have := range ldStrings(obj["@type"]) {
+		for _, want := range types {
+			if have == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func ldList(v any) []any {
+	if l, ok := v.([]any); ok {
+		return l
+	}
+	if v == nil {
+		return nil
+	}
+	return []any{v}
+}
+
+// ldString reads a text value, which JSON-LD may give as a string, number
+// or an object with a name.
+func ldString(v any) string {
+	switch t := v.(type) {
+	case string:
+		return strings.TrimSpace(t)
+	case float64:
+		return fmt.Sprintf("%g", t)
+	case map[string]any:
+		return ldString(t["name"])
+	case []any:
+		if len(t) > 0 {
+			return ldString(t[0])
+		}
+	}
+	return ""
+}
+
+func ldStrings(v any) []string {
+	var out []string
+	for _, item := range ldList(v) {
+		if s := ldString(item); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// clean collapses whitespace.
+func clean(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
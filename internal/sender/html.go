@@ -0,0 +1,209 @@
+package sender
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html"
+	"html/template"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/dantezy/cold-send0r-bot/internal/config"
+	"github.com/dantezy/cold-send0r-bot/internal/models"
+	"gopkg.in/gomail.v2"
+)
+
+// Body formats for config.EmailConfig.Format.
+const (
+	FormatText = "text"
+	FormatHTML = "html"
+	FormatBoth = "both"
+)
+
+//go:embed templates/*.tmpl
+var builtinTemplates embed.FS
+
+// HTMLData is what the HTML email template is executed against. Body is the
+// plain-text body already converted to HTML.
+type HTMLData struct {
+	Subject     string
+	Body        template.HTML
+	Contact     models.Contact
+	SenderName  string
+	SenderEmail string
+	Links       map[string]string
+	LinkLabels  []string
+}
+
+// Renderer turns plain-text bodies into the configured MIME parts.
+type Renderer struct {
+	format string
+	tmpl   *template.Template
+	sender config.SenderConfig
+}
+
+// NewRenderer parses the HTML templates named in cfg, falling back to the
+// built-in ones for any path left empty. Text-only renderers skip parsing.
+func NewRenderer(cfg config.EmailConfig, sender config.SenderConfig) (*Renderer, error) {
+	r := &Renderer{format: cfg.Format, sender: sender}
+	switch r.format {
+	case "":
+		r.format = FormatText
+	case FormatText, FormatHTML, FormatBoth:
+	default:
+		return nil, fmt.Errorf("unknown email format %q (want %s, %s or %s)", cfg.Format, FormatText, FormatHTML, FormatBoth)
+	}
+	if r.format == FormatText {
+		return r, nil
+	}
+
+	// The built-in signature goes first so a custom email template can
+	// redefine it; an explicit signature template always wins. A signature
+	// file may either {{define "signature"}} or just contain the markup.
+	sources := []struct{ name, path string }{{"signature", ""}, {"email", cfg.HTMLTemplate}}
+	if cfg.SignatureTemplate != "" {
+		sources = append(sources, struct{ name, path string }{"signature", cfg.SignatureTemplate})
+	}
+	tmpl := template.New("")
+	for _, src := range sources {
+		data, err := readTemplate(src.name, src.path)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tmpl.New(src.name).Parse(data); err != nil {
+			return nil, fmt.Errorf("parsing %s template: %w", src.name, err)
+		}
+	}
+	r.tmpl = tmpl
+	return r, nil
+}
+
+func readTemplate(name, path string) (string, error) {
+	var data []byte
+	var err error
+	if path == "" {
+		data, err = builtinTemplates.ReadFile("templates/" + name + ".html.tmpl")
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("reading %s template: %w", name, err)
+	}
+	return string(data), nil
+}
+
+// HTML renders body through the email template.
+func (r *Renderer) HTML(subject, body string, c models.Contact) (string, error) {
+	labels := make([]string, 0, len(r.sender.Links))
+	for label := range r.sender.Links {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+
+	var buf bytes.Buffer
+	err := r.tmpl.ExecuteTemplate(&buf, "email", HTMLData{
+		Subject:     subject,
+		Body:        template.HTML(TextToHTML(body)),
+		Contact:     c,
+		SenderName:  r.sender.Name,
+		SenderEmail: r.sender.Email,
+		Links:       r.sender.Links,
+		LinkLabels:  labels,
+	})
+	if err != nil {
+		return "", fmt.Errorf("rendering HTML email: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// setBody sets m's body parts for the configured format. A nil renderer
+// sends plain text only.
+func (r *Renderer) setBody(m *gomail.Message, subject, body string, c models.Contact) error {
+	if r == nil || r.format == FormatText {
+		m.SetBody("text/plain", body)
+		return nil
+	}
+
+	htmlBody, err := r.HTML(subject, body, c)
+	if err != nil {
+		return err
+	}
+	if r.format == FormatHTML {
+		m.SetBody("text/html", htmlBody)
+		return nil
+	}
+	m.SetBody("text/plain", body)
+	m.AddAlternative("text/html", htmlBody)
+	return nil
+}
+
+var (
+	listItem = regexp.MustCompile(`^\s*[-*•]\s+`)
+	inline   = regexp.MustCompile(`\[([^\]]+)\]\((https?://[^\s)]+)\)|\*\*([^*]+)\*\*|(https?://[^\s<>()]*[^\s<>().,;:!?'"])`)
+)
+
+// TextToHTML converts a plain-text body to HTML. Blank lines separate
+// paragraphs, single newlines become line breaks, "- " lines become lists,
+// and bare URLs, [text](url) links and **bold** are rendered. Everything
+// else is escaped.
+func TextToHTML(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+
+	var b strings.Builder
+	for _, para := range strings.Split(text, "\n\n") {
+		para = strings.Trim(para, "\n")
+		if strings.TrimSpace(para) == "" {
+			continue
+		}
+		lines := strings.Split(para, "\n")
+
+		isList := true
+		for _, line := range lines {
+			if !listItem.MatchString(line) {
+				isList = false
+				break
+			}
+		}
+
+		if isList {
+			b.WriteString("<ul>\n")
+			for _, line := range lines {
+				b.WriteString("<li>" + inlineHTML(listItem.ReplaceAllString(line, "")) + "</li>\n")
+			}
+			b.WriteString("</ul>\n")
+			continue
+		}
+
+		for i, line := range lines {
+			lines[i] = inlineHTML(line)
+		}
+		b.WriteString("<p>" + strings.Join(lines, "<br>\n") + "</p>\n")
+	}
+	return b.String()
+}
+
+func inlineHTML(s string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range inline.FindAllStringSubmatchIndex(s, -1) {
+		b.WriteString(html.EscapeString(s[last:m[0]]))
+		switch {
+		case m[2] >= 0:
+			b.WriteString(link(s[m[4]:m[5]], s[m[2]:m[3]]))
+		case m[6] >= 0:
+			b.WriteString("<strong>" + html.EscapeString(s[m[6]:m[7]]) + "</strong>")
+		default:
+			b.WriteString(link(s[m[8]:m[9]], s[m[8]:m[9]]))
+		}
+		last = m[1]
+	}
+	b.WriteString(html.EscapeString(s[last:]))
+	return b.String()
+}
+
+func link(href, text string) string {
+	return `<a href="` + html.EscapeString(href) + `">` + html.EscapeString(text) + `</a>`
+}
@@ -17,15 +17,19 @@ type SMTPSender struct {
 	senderEmail string
 	senderName  string
 	attachments []string
+	body        *Renderer
 	rateLimiter *time.Ticker
 }
 
-func NewSMTPSender(smtpCfg config.SMTPConfig, senderEmail, senderName string, attachments []string) *SMTPSender {
+// NewSMTPSender creates a sender. body chooses the MIME parts of each
+// message; nil sends plain text only.
+func NewSMTPSender(smtpCfg config.SMTPConfig, senderEmail, senderName string, attachments []string, body *Renderer) *SMTPSender {
 	return &SMTPSender{
 		cfg:         smtpCfg,
 		senderEmail: senderEmail,
 		senderName:  senderName,
 		attachments: attachments,
+		body:        body,
 		rateLimiter: time.NewTicker(time.Duration(smtpCfg.RateLimitMs) * time.Millisecond),
 	}
 }
@@ -41,8 +45,12 @@ func (s *SMTPSender) Send(email *models.Email) error {
 	m.SetAddressHeader("From", s.senderEmail, s.senderName)
 	m.SetHeader("To", email.Contact.Email)
 	m.SetHeader("Subject", email.Subject)
-	m.SetHeader("Message-ID", generateMessageID(s.senderEmail))
-	m.SetBody("text/plain", email.Body)
+	messageID := generateMessageID(s.senderEmail)
+	m.SetHeader("Message-ID", messageID)
+	if err := s.body.setBody(m, email.Subject, email.Body, email.Contact); err != nil {
+		email.Status = models.StatusFailed
+		return err
+	}
 
 	for _, attachment := range s.attachments {
 		m.Attach(attachment)
@@ -51,7 +59,7 @@ func (s *SMTPSender) Send(email *models.Email) error {
 	d := gomail.NewDialer(s.cfg.Host, s.cfg.Port, s.cfg.Username, s.cfg.Password)
 
 	if err := d.DialAndSend(m); err != nil {
-		email.Status = "failed"
+		email.Status = models.StatusFailed
 		log.Error().
 			Str("to", email.Contact.Email).
 			Err(err).
@@ -59,7 +67,10 @@ func (s *SMTPSender) Send(email *models.Email) error {
 		return fmt.Errorf("sending email to %s: %w", email.Contact.Email, err)
 	}
 
-	email.Status = "sent"
+	now := time.Now()
+	email.Status = models.StatusSent
+	email.MessageID = messageID
+	email.SentAt = &now
 	log.Info().
 		Str("to", email.Contact.Email).
 		Str("subject", email.Subject).
@@ -68,6 +79,59 @@ func (s *SMTPSender) Send(email *models.Email) error {
 	return nil
 }
 
+// SendFollowUp sends fu as a reply in email's thread. In-Reply-To points at
+// the most recent message sent to the contact and References lists the
+// whole thread, so mail clients group it with the original.
+func (s *SMTPSender) SendFollowUp(email *models.Email, fu *models.FollowUp) error {
+	<-s.rateLimiter.C
+
+	if s.cfg.Username == "" || s.cfg.Password == "" {
+		return fmt.Errorf("SMTP credentials not configured (set %s and %s env vars)", s.cfg.UsernameEnv, s.cfg.PasswordEnv)
+	}
+	if email.MessageID == "" {
+		return fmt.Errorf("original email to %s has no Message-ID to reply to", email.Contact.Email)
+	}
+
+	references := []string{email.MessageID}
+	for _, prev := range email.FollowUps {
+		if prev.MessageID != "" && prev.Step < fu.Step {
+			references = append(references, prev.MessageID)
+		}
+	}
+
+	m := gomail.NewMessage()
+	m.SetAddressHeader("From", s.senderEmail, s.senderName)
+	m.SetHeader("To", email.Contact.Email)
+	m.SetHeader("Subject", fu.Subject)
+	messageID := generateMessageID(s.senderEmail)
+	m.SetHeader("Message-ID", messageID)
+	m.SetHeader("In-Reply-To", references[len(references)-1])
+	m.SetHeader("References", strings.Join(references, " "))
+	if err := s.body.setBody(m, fu.Subject, fu.Body, email.Contact); err != nil {
+		return err
+	}
+
+	d := gomail.NewDialer(s.cfg.Host, s.cfg.Port, s.cfg.Username, s.cfg.Password)
+	if err := d.DialAndSend(m); err != nil {
+		log.Error().
+			Str("to", email.Contact.Email).
+			Int("step", fu.Step).
+			Err(err).
+			Msg("failed to send follow-up")
+		return fmt.Errorf("sending follow-up to %s: %w", email.Contact.Email, err)
+	}
+
+	now := time.Now()
+	fu.MessageID = messageID
+	fu.SentAt = &now
+	log.Info().
+		Str("to", email.Contact.Email).
+		Int("step", fu.Step).
+		Msg("follow-up sent")
+
+	return nil
+}
+
 func generateMessageID(senderEmail string) string {
 	b := make([]byte, 16)
 	_, _ = rand.Read(b)
@@ -0,0 +1,220 @@
+package store
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/dantezy/cold-send0r-bot/internal/models"
+)
+
+// Stage is how far a contact has progressed through the pipeline.
+type Stage string
+
+const (
+	StagePending   Stage = "pending"
+	StageScraped   Stage = "scraped"
+	StageGenerated Stage = "generated"
+	StageSent      Stage = "sent"
+	StageFailed    Stage = "failed"
+)
+
+type Record struct {
+	Contact   models.Contact       `json:"contact"`
+	Stage     Stage                `json:"stage"`
+	Scrape    *models.ScrapeResult `json:"scrape,omitempty"`
+	Email     *models.Email        `json:"email,omitempty"`
+	Error     string               `json:"error,omitempty"`
+	UpdatedAt time.Time            `json:"updated_at"`
+}
+
+// Store is a local campaign database keyed by contact email. Every update is
+// appended to a JSON Lines log and fsynced, so a crash loses at most the
+// record being written. The log is compacted on Open.
+type Store struct {
+	path    string
+	mu      sync.Mutex
+	f       *os.File
+	order   []string
+	records map[string]*Record
+}
+
+func Open(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("creating state directory: %w", err)
+	}
+
+	s := &Store{path: path, records: make(map[string]*Record)}
+	if err := s.replay(); err != nil {
+		return nil, err
+	}
+	if err := s.compact(); err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("opening state file: %w", err)
+	}
+	s.f = f
+	return s, nil
+}
+
+func Key(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func (s *Store) Get(email string) (Record, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, ok := s.records[Key(email)]
+	if !ok {
+		return Record{}, false
+	}
+	return *rec, true
+}
+
+// Put stores rec and durably appends it to the log before returning.
+func (s *Store) Put(rec Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec.UpdatedAt = time.Now()
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("marshaling state record: %w", err)
+	}
+	if _, err := s.f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("writing state record: %w", err)
+	}
+	if err := s.f.Sync(); err != nil {
+		return fmt.Errorf("syncing state file: %w", err)
+	}
+
+	s.set(rec)
+	return nil
+}
+
+// Records returns all records in the order contacts were first stored.
+func (s *Store) Records() []Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]Record, 0, len(s.order))
+	for _, k := range s.order {
+		out = append(out, *s.records[k])
+	}
+	return out
+}
+
+// Emails returns every generated email, ready for output.WriteEmails.
+func (s *Store) Emails() []models.Email {
+	var emails []models.Email
+	for _, rec := range s.Records() {
+		if rec.Email != nil {
+			emails = append(emails, *rec.Email)
+		}
+	}
+	return emails
+}
+
+// Reset discards all records, starting a fresh campaign.
+func (s *Store) Reset() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.f.Truncate(0); err != nil {
+		return fmt.Errorf("truncating state file: %w", err)
+	}
+	s.order = nil
+	s.records = make(map[string]*Record)
+	return nil
+}
+
+func (s *Store) Close() error {
+	return s.f.Close()
+}
+
+func (s *Store) set(rec Record) {
+	k := Key(rec.Contact.Email)
+	if _, ok := s.records[k]; !ok {
+		s.order = append(s.order, k)
+	}
+	s.records[k] = &rec
+}
+
+func (s *Store) replay() error {
+	f, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("opening state file: %w", err)
+	}
+	defer f.Close()
+
+	// A torn final line from a crash mid-write is dropped; everything before
+	// it is intact. A bad line anywhere else fails the load, as compact would
+	// otherwise rewrite the file without the records after it.
+	var torn error
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for n := 1; sc.Scan(); n++ {
+		line := sc.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		if torn != nil {
+			return torn
+		}
+		var rec Record
+		if err := json.Unmarshal(line, &rec); err != nil {
+			torn = fmt.Errorf("state file %s line %d is corrupt: %w", s.path, n, err)
+			continue
+		}
+		s.set(rec)
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("reading state file: %w", err)
+	}
+	if torn != nil {
+		log.Warn().Err(torn).Msg("dropping torn last line of the state file")
+	}
+	return nil
+}
+
+func (s *Store) compact() error {
+	tmp := s.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("creating state file: %w", err)
+	}
+
+	w := bufio.NewWriter(f)
+	for _, k := range s.order {
+		data, err := json.Marshal(s.records[k])
+		if err != nil {
+			f.Close()
+			return fmt.Errorf("marshaling state record: %w", err)
+		}
+		w.Write(data)
+		w.WriteByte('\n')
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return fmt.Errorf("writing state file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("closing state file: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
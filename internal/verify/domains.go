@@ -0,0 +1,114 @@
+package verify
+
+import "strings"
+
+// disposableDomains are throwaway inbox services. The list covers the
+// common ones; add more with validation.disposable_domains.
+var disposableDomains = []string{
+	"10minutemail.com", "20minutemail.com", "33mail.com", "burnermail.io", "byom.de",
+	"discard.email", "dispostable.com", "dropmail.me", "emailfake.com", "emailondeck.com",
+	"fakeinbox.com", "getairmail.com", "getnada.com", "grr.la", "guerrillamail.biz",
+	"guerrillamail.com", "guerrillamail.de", "guerrillamail.net", "guerrillamail.org", "guerrillamailblock.com",
+	"inboxkitten.com", "mailcatch.com", "maildrop.cc", "mailinator.com", "mailinator.net",
+	"mailnesia.com", "mailpoof.com", "mailsac.com", "mintemail.com", "minuteinbox.com",
+	"moakt.com", "mohmal.com", "mytemp.email", "nada.email", "pokemail.net",
+	"sharklasers.com", "spam4.me", "spambox.us", "spamgourmet.com", "tempail.com",
+	"tempinbox.com", "tempmail.com", "tempmail.net", "tempmailo.com", "temp-mail.io",
+	"temp-mail.org", "tempr.email", "throwawaymail.com", "trash-mail.com", "trashmail.com",
+	"trashmail.de", "yopmail.com", "yopmail.fr", "yopmail.net",
+}
+
+// noReplyMailboxes never read replies.
+var noReplyMailboxes = map[string]bool{
+	"noreply": true, "no-reply": true, "no_reply": true, "donotreply": true, "do-not-reply": true,
+	"do_not_reply": true, "bounce": true, "bounces": true, "mailer-daemon": true, "notifications": true,
+}
+
+// roleMailboxes are shared inboxes that rarely reach a decision maker.
+// Recruiting inboxes such as jobs@, careers@ and hiring@ are left out on
+// purpose: they are where an application belongs.
+var roleMailboxes = map[string]bool{
+	"abuse": true, "accounts": true, "admin": true, "administrator": true, "billing": true,
+	"contact": true, "enquiries": true, "feedback": true, "hello": true, "help": true,
+	"hostmaster": true, "info": true, "inquiries": true, "legal": true, "mail": true,
+	"marketing": true, "media": true, "newsletter": true, "office": true, "postmaster": true,
+	"press": true, "privacy": true, "root": true, "sales": true, "security": true,
+	"service": true, "support": true, "team": true, "webmaster": true,
+}
+
+// domainTypos are misspellings of mail providers seen often enough to fix
+// outright; other near misses are found by edit distance.
+var domainTypos = map[string]string{
+	"gamil.com": "gmail.com", "gmai.com": "gmail.com", "gmial.com": "gmail.com", "gmal.com": "gmail.com",
+	"gmaill.com": "gmail.com", "gnail.com": "gmail.com", "gmail.co": "gmail.com", "gmail.cm": "gmail.com",
+	"googlemail.co": "googlemail.com", "hotmai.com": "hotmail.com", "hotmial.com": "hotmail.com",
+	"hotmal.com": "hotmail.com", "homail.com": "hotmail.com", "outlok.com": "outlook.com",
+	"outloo.com": "outlook.com", "yaho.com": "yahoo.com", "yahooo.com": "yahoo.com", "yhoo.com": "yahoo.com",
+	"iclod.com": "icloud.com", "icoud.com": "icloud.com", "protonmail.co": "protonmail.com",
+}
+
+// tldTypos are mistyped top-level domains.
+var tldTypos = map[string]string{
+	"con": "com", "cmo": "com", "ocm": "com", "vom": "com", "xom": "com", "cpm": "com",
+	"comm": "com", "coom": "com", "nte": "net", "ent": "net", "ogr": "org", "rog": "org",
+}
+
+// providers are mail providers whose near misses are suggested. Short
+// names such as aol.com are left out, as too many real domains are one
+// letter away from them.
+var providers = []string{
+	"gmail.com", "googlemail.com", "yahoo.com", "hotmail.com", "outlook.com",
+	"icloud.com", "protonmail.com",
+}
+
+// knownDomains are real domains within one edit of a provider.
+var knownDomains = map[string]bool{
+	"email.com": true, "mail.com": true, "ymail.com": true,
+}
+
+// suggest returns the domain that was probably meant, or "".
+func suggest(domain string) string {
+	if fix, ok := domainTypos[domain]; ok {
+		return fix
+	}
+	if i := strings.LastIndex(domain, "."); i > 0 {
+		if tld, ok := tldTypos[domain[i+1:]]; ok {
+			return domain[:i+1] + tld
+		}
+	}
+	if knownDomains[domain] {
+		return ""
+	}
+	for _, p := range providers {
+		if domain != p && distance(domain, p) == 1 {
+			return p
+		}
+	}
+	return ""
+}
+
+// distance is the optimal string alignment distance between a and b:
+// insertions, deletions, substitutions and swaps of adjacent letters.
+func distance(a, b string) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
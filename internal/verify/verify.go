@@ -0,0 +1,218 @@
+// Package verify checks email addresses before they are mailed: syntax,
+// common domain typos, disposable domains, role and no-reply accounts, and
+// whether the domain has MX or A records.
+package verify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dantezy/cold-send0r-bot/internal/config"
+	"github.com/dantezy/cold-send0r-bot/internal/models"
+)
+
+const defaultTimeout = 5 * time.Second
+
+// canary always has MX records. A resolver that cannot find them answers
+// "not found" for everything, as captive portals and sinkholes do, so its
+// answers are not trusted.
+const canary = "gmail.com"
+
+// Resolver looks up DNS records. *net.Resolver implements it.
+type Resolver interface {
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// Checker validates addresses. DNS results are remembered per domain, so
+// a list with many contacts at one company costs one lookup.
+type Checker struct {
+	resolver   Resolver
+	timeout    time.Duration
+	disposable map[string]bool
+
+	mu      sync.Mutex
+	domains map[string]*domainCheck
+
+	probe   sync.Once
+	trusted bool
+}
+
+type domainCheck struct {
+	once    sync.Once
+	verdict string
+	reason  string
+}
+
+// NewChecker builds a checker from cfg. resolver is used for the MX/A
+// lookups unless cfg.SkipDNS is set; nil also skips them.
+func NewChecker(cfg config.ValidationConfig, resolver Resolver) *Checker {
+	c := &Checker{
+		timeout:    time.Duration(cfg.TimeoutMs) * time.Millisecond,
+		disposable: make(map[string]bool, len(disposableDomains)+len(cfg.DisposableDomains)),
+		domains:    make(map[string]*domainCheck),
+	}
+	if !cfg.SkipDNS {
+		c.resolver = resolver
+	}
+	if c.timeout <= 0 {
+		c.timeout = defaultTimeout
+	}
+	for _, d := range disposableDomains {
+		c.disposable[d] = true
+	}
+	for _, d := range cfg.DisposableDomains {
+		c.disposable[strings.ToLower(strings.TrimSpace(d))] = true
+	}
+	return c
+}
+
+// Check returns the verdict on addr.
+func (c *Checker) Check(ctx context.Context, addr string) *models.Validation {
+	v := &models.Validation{Verdict: models.VerdictValid, CheckedAt: time.Now().UTC()}
+
+	parsed, err := mail.ParseAddress(addr)
+	if err != nil {
+		flag(v, models.VerdictInvalid, "malformed address")
+		return v
+	}
+	at := strings.LastIndex(parsed.Address, "@")
+	local := strings.ToLower(parsed.Address[:at])
+	domain := strings.TrimSuffix(strings.ToLower(parsed.Address[at+1:]), ".")
+	if !strings.Contains(domain, ".") {
+		flag(v, models.VerdictInvalid, "domain has no top-level domain")
+		return v
+	}
+
+	// Only risky: a real domain can sit one letter away from a provider
+	if fix := suggest(domain); fix != "" {
+		v.Suggestion = parsed.Address[:at] + "@" + fix
+		flag(v, models.VerdictRisky, fmt.Sprintf("%s looks like a typo of %s", domain, fix))
+	}
+	if c.isDisposable(domain) {
+		v.Disposable = true
+		flag(v, models.VerdictInvalid, "disposable email domain")
+	}
+
+	// Tags such as "info+web" are still the info mailbox
+	mailbox, _, _ := strings.Cut(local, "+")
+	switch {
+	case noReplyMailboxes[mailbox] || strings.HasPrefix(mailbox, "noreply") || strings.HasPrefix(mailbox, "no-reply"):
+		v.Role = true
+		flag(v, models.VerdictInvalid, "no-reply address")
+	case roleMailboxes[mailbox]:
+		v.Role = true
+		flag(v, models.VerdictRisky, "role account, likely not read by a person")
+	}
+
+	// Disposable domains resolve, so skip the lookup
+	if c.resolver != nil && v.Verdict != models.VerdictInvalid {
+		if verdict, reason := c.lookup(ctx, domain); verdict != models.VerdictValid {
+			flag(v, verdict, reason)
+		}
+	}
+	return v
+}
+
+// flag adds a reason and raises the verdict to at least verdict.
+func flag(v *models.Validation, verdict, reason string) {
+	v.Reasons = append(v.Reasons, reason)
+	if severity(verdict) > severity(v.Verdict) {
+		v.Verdict = verdict
+	}
+}
+
+func severity(verdict string) int {
+	switch verdict {
+	case models.VerdictUnknown:
+		return 1
+	case models.VerdictRisky:
+		return 2
+	case models.VerdictInvalid:
+		return 3
+	}
+	return 0
+}
+
+func (c *Checker) isDisposable(domain string) bool {
+	// Disposable services hand out subdomains too
+	for d := domain; ; {
+		if c.disposable[d] {
+			return true
+		}
+		_, rest, ok := strings.Cut(d, ".")
+		if !ok || !strings.Contains(rest, ".") {
+			return false
+		}
+		d = rest
+	}
+}
+
+func (c *Checker) lookup(ctx context.Context, domain string) (string, string) {
+	c.mu.Lock()
+	dc, ok := c.domains[domain]
+	if !ok {
+		dc = &domainCheck{}
+		c.domains[domain] = dc
+	}
+	c.mu.Unlock()
+
+	dc.once.Do(func() {
+		dc.verdict, dc.reason = c.resolve(ctx, domain)
+	})
+	return dc.verdict, dc.reason
+}
+
+// resolve checks that domain can receive mail: it has MX records that are
+// not a null MX (RFC 7505), or failing that an A or AAAA record, which
+// senders fall back to.
+func (c *Checker) resolve(ctx context.Context, domain string) (string, string) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	mxs, err := c.resolver.LookupMX(ctx, domain)
+	if err == nil && len(mxs) > 0 {
+		if len(mxs) == 1 && (mxs[0].Host == "." || mxs[0].Host == "") {
+			return models.VerdictInvalid, "domain does not accept email"
+		}
+		return models.VerdictValid, ""
+	}
+	if err != nil && !notFound(err) {
+		return models.VerdictUnknown, fmt.Sprintf("MX lookup failed: %v", err)
+	}
+
+	hosts, err := c.resolver.LookupHost(ctx, domain)
+	switch {
+	case err == nil && len(hosts) > 0:
+		return models.VerdictValid, ""
+	case err != nil && !notFound(err):
+		return models.VerdictUnknown, fmt.Sprintf("DNS lookup failed: %v", err)
+	case !c.trustResolver(ctx):
+		return models.VerdictUnknown, fmt.Sprintf("DNS resolver cannot find %s either, MX check skipped", canary)
+	default:
+		return models.VerdictInvalid, fmt.Sprintf("no MX or A records for %s", domain)
+	}
+}
+
+// trustResolver reports whether the resolver finds the canary's MX
+// records. It is asked once, when a domain first comes back not found.
+func (c *Checker) trustResolver(ctx context.Context) bool {
+	c.probe.Do(func() {
+		ctx, cancel := context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+		mxs, err := c.resolver.LookupMX(ctx, canary)
+		c.trusted = err == nil && len(mxs) > 0
+	})
+	return c.trusted
+}
+
+func notFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}